@@ -0,0 +1,267 @@
+package alerts
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
+)
+
+// The types of rules supported
+const (
+	// A validator is missing from the committed seals of N consecutive blocks
+	RuleMissingSeals = "missing_seals"
+
+	// No new block was received for T seconds
+	RuleNoBlock = "no_block"
+
+	// The interval between two consecutive blocks is greater than T seconds
+	RuleBlockInterval = "block_interval"
+
+	// Our node is more than N blocks behind the highest block known by its peers
+	RuleNodeLagging = "node_lagging"
+
+	// The stall detector reports that the chain stopped producing blocks
+	RuleChainStalled = "chain_stalled"
+)
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Config is the contents of the alerting rules file, in YAML format.
+//
+//	rules:
+//	  - name: validator-not-signing
+//	    type: missing_seals
+//	    blocks: 10
+//	    severity: critical
+//	  - name: chain-stalled
+//	    type: no_block
+//	    seconds: 30
+//	notifiers:
+//	  - type: slack
+//	    url: https://hooks.slack.com/services/...
+type Config struct {
+	Rules     []RuleConfig     `yaml:"rules"`
+	Notifiers []NotifierConfig `yaml:"notifiers"`
+
+	// The mail server, required by the email notifier and the daily digest
+	SMTP *SMTPConfig `yaml:"smtp"`
+
+	// Optional daily summary of activity, sent by email
+	Digest *DigestConfig `yaml:"digest"`
+
+	// How often the time-based rules are evaluated, in seconds
+	CheckInterval int `yaml:"checkInterval"`
+}
+
+type RuleConfig struct {
+	Name     string   `yaml:"name"`
+	Type     string   `yaml:"type"`
+	Severity Severity `yaml:"severity"`
+
+	// For missing_seals, the validator to watch. All validators if empty
+	Validator string `yaml:"validator"`
+
+	// The threshold for missing_seals and node_lagging
+	Blocks int64 `yaml:"blocks"`
+
+	// The threshold for no_block and block_interval
+	Seconds int64 `yaml:"seconds"`
+}
+
+type NotifierConfig struct {
+	// One of "webhook", "slack", "mattermost" or "email"
+	Type string `yaml:"type"`
+	URL  string `yaml:"url"`
+
+	// For email, the recipients and the minimum severity sent (critical by default)
+	To          []string `yaml:"to"`
+	MinSeverity Severity `yaml:"minSeverity"`
+
+	// Optional settings for Slack/Mattermost incoming webhooks
+	Channel  string `yaml:"channel"`
+	Username string `yaml:"username"`
+
+	// Optional headers for generic webhooks, for example for authentication
+	Headers map[string]string `yaml:"headers"`
+}
+
+// SMTPConfig are the settings to connect to the mail server
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+
+	// One of "starttls" (the default), "tls" for implicit TLS, or "none"
+	TLS string `yaml:"tls"`
+
+	// Do not verify the certificate of the server, only for testing
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+}
+
+// DigestConfig defines who receives the daily summary of activity
+type DigestConfig struct {
+	// Local time of the day when the digest is sent, in HH:MM format. Default is 08:00
+	Time string `yaml:"time"`
+
+	// Recipients of the digest with all validators
+	To []string `yaml:"to"`
+
+	// Recipients of the digest of each operator, by operator name
+	Operators map[string][]string `yaml:"operators"`
+}
+
+const defaultCheckInterval = 5
+
+// LoadConfig reads and validates the rules file
+func LoadConfig(path string) (*Config, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	err = yaml.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %v: %w", path, err)
+	}
+
+	err = cfg.validate()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (cfg *Config) validate() error {
+
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = defaultCheckInterval
+	}
+
+	names := map[string]bool{}
+
+	for i := range cfg.Rules {
+		r := &cfg.Rules[i]
+
+		if len(r.Name) == 0 {
+			r.Name = r.Type
+		}
+		if names[r.Name] {
+			return fmt.Errorf("duplicate rule name %q", r.Name)
+		}
+		names[r.Name] = true
+
+		switch r.Severity {
+		case "":
+			r.Severity = SeverityWarning
+		case SeverityInfo, SeverityWarning, SeverityCritical:
+		default:
+			return fmt.Errorf("rule %q: invalid severity %q", r.Name, r.Severity)
+		}
+
+		switch r.Type {
+		case RuleMissingSeals, RuleNodeLagging:
+			if r.Blocks <= 0 {
+				return fmt.Errorf("rule %q: 'blocks' must be positive", r.Name)
+			}
+		case RuleNoBlock, RuleBlockInterval:
+			if r.Seconds <= 0 {
+				return fmt.Errorf("rule %q: 'seconds' must be positive", r.Name)
+			}
+		case RuleChainStalled:
+		default:
+			return fmt.Errorf("rule %q: unknown type %q", r.Name, r.Type)
+		}
+
+		if len(r.Validator) > 0 && !common.IsHexAddress(r.Validator) {
+			return fmt.Errorf("rule %q: invalid validator address %q", r.Name, r.Validator)
+		}
+	}
+
+	for i := range cfg.Notifiers {
+		n := &cfg.Notifiers[i]
+		switch n.Type {
+		case "webhook", "slack", "mattermost":
+			if len(n.URL) == 0 {
+				return fmt.Errorf("notifier %q without url", n.Type)
+			}
+		case "email":
+			if cfg.SMTP == nil {
+				return fmt.Errorf("email notifier requires the smtp section")
+			}
+			if len(n.To) == 0 {
+				return fmt.Errorf("email notifier without recipients")
+			}
+			if len(n.MinSeverity) == 0 {
+				n.MinSeverity = SeverityCritical
+			}
+		default:
+			return fmt.Errorf("unknown notifier type %q", n.Type)
+		}
+	}
+
+	if cfg.SMTP != nil {
+		if err := cfg.SMTP.validate(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Digest != nil {
+		if cfg.SMTP == nil {
+			return fmt.Errorf("digest requires the smtp section")
+		}
+		if len(cfg.Digest.Time) == 0 {
+			cfg.Digest.Time = "08:00"
+		}
+		if _, err := time.Parse("15:04", cfg.Digest.Time); err != nil {
+			return fmt.Errorf("invalid digest time %q", cfg.Digest.Time)
+		}
+	}
+
+	return nil
+}
+
+func (c *SMTPConfig) validate() error {
+	if len(c.Host) == 0 || len(c.From) == 0 {
+		return fmt.Errorf("smtp requires host and from")
+	}
+
+	switch c.TLS {
+	case "":
+		c.TLS = "starttls"
+	case "starttls", "tls", "none":
+	default:
+		return fmt.Errorf("invalid smtp tls mode %q", c.TLS)
+	}
+
+	// Use the standard port for the TLS mode if not specified
+	if c.Port == 0 {
+		switch c.TLS {
+		case "starttls":
+			c.Port = 587
+		case "tls":
+			c.Port = 465
+		default:
+			c.Port = 25
+		}
+	}
+
+	return nil
+}
+
+func (r *RuleConfig) threshold() time.Duration {
+	return time.Duration(r.Seconds) * time.Second
+}
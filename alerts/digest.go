@@ -0,0 +1,226 @@
+package alerts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/history"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/rs/zerolog/log"
+)
+
+// digestEntry is the activity of a validator in the period of the digest
+type digestEntry struct {
+	Address     common.Address
+	Operator    string
+	Proposals   int64
+	Seals       int64
+	MissedSeals int64
+}
+
+// Uptime is the percentage of blocks sealed by the validator
+func (d digestEntry) Uptime() string {
+	total := d.Seals + d.MissedSeals
+	if total == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(d.Seals)/float64(total))
+}
+
+// digest sends a daily summary of the activity of the validators. With a history database, the data
+// comes from the blocks of the last 24 hours stored there, which the server stores as it processes them.
+// Without it, the data comes from the in-memory counters since the previous digest.
+type digest struct {
+	cfg *DigestConfig
+	rt  *redt.RedTNode
+	db  *history.Blockchain
+
+	// Sends a message to the recipients, with the mail server
+	sendMail func(to []string, subject string, body string) error
+
+	// The counters when the previous digest was sent
+	previous map[common.Address]redt.ValidatorStats
+	since    time.Time
+}
+
+func newDigest(cfg *DigestConfig, smtpConfig *SMTPConfig, rt *redt.RedTNode, db *history.Blockchain) *digest {
+	d := &digest{
+		cfg:      cfg,
+		rt:       rt,
+		db:       db,
+		sendMail: smtpConfig.Send,
+	}
+	d.snapshot(time.Now())
+	return d
+}
+
+// run sends the digest every day at the configured time
+func (d *digest) run() {
+	for {
+		next := nextDigestTime(time.Now(), d.cfg.Time)
+		time.Sleep(time.Until(next))
+
+		if err := d.send(next); err != nil {
+			log.Error().Err(err).Msg("sending daily digest")
+		}
+	}
+}
+
+// nextDigestTime returns the next time after now with the hour and minute specified in HH:MM format
+func nextDigestTime(now time.Time, hhmm string) time.Time {
+	t, _ := time.Parse("15:04", hhmm)
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// send builds the digest up to now and sends it to the recipients of all validators and of each operator
+func (d *digest) send(now time.Time) error {
+
+	entries, from, err := d.entries(now)
+	if err != nil {
+		return err
+	}
+
+	return d.deliver(entries, from, now)
+}
+
+// deliver sends the digest to all the recipients, even if some of them fail, and returns the errors
+func (d *digest) deliver(entries []digestEntry, from time.Time, now time.Time) error {
+
+	date := now.Format("2006-01-02")
+	var errs []error
+
+	if len(d.cfg.To) > 0 {
+		subject := fmt.Sprintf("RedT validators daily digest %v", date)
+		if err := d.sendMail(d.cfg.To, subject, formatDigest(entries, from, now)); err != nil {
+			errs = append(errs, fmt.Errorf("sending to %v: %w", strings.Join(d.cfg.To, ", "), err))
+		}
+	}
+
+	for operator, to := range d.cfg.Operators {
+
+		// Only the validators of the operator
+		var own []digestEntry
+		for _, e := range entries {
+			if strings.EqualFold(e.Operator, operator) {
+				own = append(own, e)
+			}
+		}
+		if len(own) == 0 {
+			log.Warn().Str("operator", operator).Msg("operator without validators in the digest")
+			continue
+		}
+
+		subject := fmt.Sprintf("RedT daily digest for %v %v", operator, date)
+		if err := d.sendMail(to, subject, formatDigest(own, from, now)); err != nil {
+			errs = append(errs, fmt.Errorf("sending to %v: %w", operator, err))
+		}
+	}
+
+	return joinErrors(errs)
+}
+
+// sendErrors are the errors sending a digest to several recipients
+type sendErrors []error
+
+func (e sendErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// joinErrors returns nil without errors, the error if there is only one, or all of them.
+// It replaces errors.Join, which requires Go 1.20.
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return sendErrors(errs)
+}
+
+// entries calculates the activity of each validator in the current set, and the start of the period
+func (d *digest) entries(now time.Time) ([]digestEntry, time.Time, error) {
+
+	if d.db != nil {
+		return d.entriesFromHistory(now)
+	}
+
+	// Subtract the counters of the previous digest from the current ones
+	from := d.since
+	current := d.rt.Stats()
+	entries := make([]digestEntry, len(current))
+	for i, st := range current {
+		prev := d.previous[st.Address]
+		entries[i] = digestEntry{
+			Address:     st.Address,
+			Operator:    st.Operator,
+			Proposals:   int64(st.Proposals - prev.Proposals),
+			Seals:       int64(st.Seals - prev.Seals),
+			MissedSeals: int64(st.MissedSeals - prev.MissedSeals),
+		}
+	}
+	d.snapshot(now)
+
+	return entries, from, nil
+}
+
+// entriesFromHistory calculates the activity in the last 24 hours from the history database
+func (d *digest) entriesFromHistory(now time.Time) ([]digestEntry, time.Time, error) {
+
+	from := now.Add(-24 * time.Hour)
+	numBlocks, stats, err := d.db.StatsForPeriod(from.Unix(), now.Unix())
+	if err != nil {
+		return nil, from, err
+	}
+
+	byAddress := make(map[common.Address]history.SignerStats, len(stats))
+	for _, st := range stats {
+		byAddress[common.HexToAddress(st.Address)] = st
+	}
+
+	validators := d.rt.Validators()
+	entries := make([]digestEntry, len(validators))
+	for i, val := range validators {
+		st := byAddress[val]
+		entries[i] = digestEntry{
+			Address:     val,
+			Operator:    d.rt.OperatorName(val),
+			Proposals:   st.Proposals,
+			Seals:       st.Seals,
+			MissedSeals: numBlocks - st.Seals,
+		}
+	}
+
+	return entries, from, nil
+}
+
+func (d *digest) snapshot(now time.Time) {
+	d.previous = map[common.Address]redt.ValidatorStats{}
+	for _, st := range d.rt.Stats() {
+		d.previous[st.Address] = st
+	}
+	d.since = now
+}
+
+func formatDigest(entries []digestEntry, from time.Time, to time.Time) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Activity of the validators from %v to %v\n\n", from.Format(time.RFC1123), to.Format(time.RFC1123))
+	fmt.Fprintf(&sb, "%-14v %9v %9v %9v %8v  %v\n", "Operator", "Proposals", "Seals", "Missed", "Uptime", "Address")
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "%-14v %9v %9v %9v %8v  %v\n", e.Operator, e.Proposals, e.Seals, e.MissedSeals, e.Uptime(), e.Address.Hex())
+	}
+
+	return sb.String()
+}
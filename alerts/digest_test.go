@@ -0,0 +1,51 @@
+package alerts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestDeliver(t *testing.T) {
+	d := &digest{cfg: &DigestConfig{
+		To:        []string{"noc@example.com"},
+		Operators: map[string][]string{"Alpha": {"alpha@example.com"}, "Beta": {"beta@example.com"}},
+	}}
+
+	// The mail server rejects the first recipients
+	sent := map[string]string{}
+	d.sendMail = func(to []string, subject string, body string) error {
+		sent[to[0]] = body
+		if to[0] == "noc@example.com" {
+			return errors.New("mailbox unavailable")
+		}
+		return nil
+	}
+
+	entries := []digestEntry{
+		{Address: common.HexToAddress("0x01"), Operator: "Alpha", Seals: 9, MissedSeals: 1},
+		{Address: common.HexToAddress("0x02"), Operator: "Beta", Seals: 10},
+	}
+	now := time.Date(2022, 7, 1, 8, 0, 0, 0, time.UTC)
+	err := d.deliver(entries, now.Add(-24*time.Hour), now)
+
+	// The rest are sent anyway, with their own validators only
+	assert.Len(t, sent, 3)
+	assert.Contains(t, sent["alpha@example.com"], "90.0%")
+	assert.NotContains(t, sent["alpha@example.com"], "Beta")
+	assert.Contains(t, sent["beta@example.com"], "100.0%")
+
+	assert.EqualError(t, err, "sending to noc@example.com: mailbox unavailable")
+
+	// All the failures are returned
+	d.sendMail = func(to []string, subject string, body string) error {
+		return errors.New("connection refused")
+	}
+	err = d.deliver(entries, now.Add(-24*time.Hour), now)
+	assert.Error(t, err)
+	assert.Equal(t, 3, strings.Count(err.Error(), "connection refused"))
+}
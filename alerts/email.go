@@ -0,0 +1,133 @@
+package alerts
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Send delivers a plain text message to the recipients using the mail server
+func (c *SMTPConfig) Send(to []string, subject string, body string) error {
+
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	tlsConfig := &tls.Config{ServerName: c.Host, InsecureSkipVerify: c.InsecureSkipVerify}
+	dialer := &net.Dialer{Timeout: notifyTimeout}
+
+	// Connect, directly over TLS if implicit TLS is configured
+	var conn net.Conn
+	var err error
+	if c.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(notifyTimeout))
+
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	// Upgrade the connection if required
+	if c.TLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err = client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if len(c.Username) > 0 {
+		if err = client.Auth(smtp.PlainAuth("", c.Username, c.Password, c.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(c.From); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err = client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buildMessage(c.From, to, subject, body))
+	if err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func buildMessage(from string, to []string, subject string, body string) []byte {
+	var sb strings.Builder
+
+	sb.WriteString("From: " + from + "\r\n")
+	sb.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	sb.WriteString("Subject: " + subject + "\r\n")
+	sb.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return []byte(sb.String())
+}
+
+// EmailNotifier sends an email for each alert with at least the minimum severity
+type EmailNotifier struct {
+	SMTP        *SMTPConfig
+	To          []string
+	MinSeverity Severity
+}
+
+var severityLevel = map[Severity]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+func (n *EmailNotifier) Notify(a *Alert) error {
+	if severityLevel[a.Severity] < severityLevel[n.MinSeverity] {
+		return nil
+	}
+
+	subject := fmt.Sprintf("[%v] %v: %v", strings.ToUpper(string(a.Status)), a.Severity, a.Rule)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%v\n\n", a.Summary)
+	fmt.Fprintf(&sb, "Rule:      %v (%v)\n", a.Rule, a.Type)
+	fmt.Fprintf(&sb, "Severity:  %v\n", a.Severity)
+	fmt.Fprintf(&sb, "Status:    %v\n", a.Status)
+	if len(a.Validator) > 0 {
+		fmt.Fprintf(&sb, "Validator: %v %v\n", a.Operator, a.Validator)
+	}
+	if a.Block > 0 {
+		fmt.Fprintf(&sb, "Block:     %v\n", a.Block)
+	}
+	fmt.Fprintf(&sb, "Started:   %v\n", a.StartsAt.Format(time.RFC1123))
+	if a.EndsAt != nil {
+		fmt.Fprintf(&sb, "Resolved:  %v\n", a.EndsAt.Format(time.RFC1123))
+	}
+
+	return n.SMTP.Send(n.To, subject, sb.String())
+}
@@ -0,0 +1,106 @@
+package alerts
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpStandIn is a minimal SMTP server accepting a single message, without TLS or authentication
+func smtpStandIn(t *testing.T) (port int, messages chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	messages = make(chan string, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				messages <- data.String()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("500 Unknown command")
+			}
+		}
+	}()
+
+	_, portStr, _ := net.SplitHostPort(l.Addr().String())
+	port, _ = strconv.Atoi(portStr)
+	return port, messages
+}
+
+func TestEmailNotifier(t *testing.T) {
+	port, messages := smtpStandIn(t)
+
+	smtpConfig := &SMTPConfig{Host: "127.0.0.1", Port: port, From: "signers@example.com", TLS: "none"}
+	assert.NoError(t, smtpConfig.validate())
+
+	n := &EmailNotifier{SMTP: smtpConfig, To: []string{"ops@example.com"}, MinSeverity: SeverityCritical}
+
+	// Warnings are not sent
+	assert.NoError(t, n.Notify(&Alert{Rule: "slow", Severity: SeverityWarning}))
+
+	err := n.Notify(&Alert{
+		Rule:     "chain-stalled",
+		Type:     RuleNoBlock,
+		Severity: SeverityCritical,
+		Status:   StatusFiring,
+		Summary:  "No new block for 40 seconds after block 100",
+		StartsAt: time.Now(),
+	})
+	assert.NoError(t, err)
+
+	select {
+	case msg := <-messages:
+		assert.Contains(t, msg, "Subject: [FIRING] critical: chain-stalled")
+		assert.Contains(t, msg, "To: ops@example.com")
+		assert.Contains(t, msg, "No new block for 40 seconds after block 100")
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestNextDigestTime(t *testing.T) {
+	now := time.Date(2022, 7, 1, 10, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2022, 7, 1, 18, 0, 0, 0, time.UTC), nextDigestTime(now, "18:00"))
+	assert.Equal(t, time.Date(2022, 7, 2, 8, 0, 0, 0, time.UTC), nextDigestTime(now, "08:00"))
+}
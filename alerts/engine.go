@@ -0,0 +1,370 @@
+package alerts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/history"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/rs/zerolog/log"
+)
+
+type Status string
+
+const (
+	StatusFiring   Status = "firing"
+	StatusResolved Status = "resolved"
+)
+
+// Alert is a notification that a rule started or stopped matching
+type Alert struct {
+	Rule      string     `json:"rule"`
+	Type      string     `json:"type"`
+	Severity  Severity   `json:"severity"`
+	Status    Status     `json:"status"`
+	Validator string     `json:"validator,omitempty"`
+	Operator  string     `json:"operator,omitempty"`
+	Summary   string     `json:"summary"`
+	Block     int64      `json:"block,omitempty"`
+	StartsAt  time.Time  `json:"startsAt"`
+	EndsAt    *time.Time `json:"endsAt,omitempty"`
+}
+
+// Notifier delivers alerts to an external system
+type Notifier interface {
+	Notify(a *Alert) error
+}
+
+// Size of the queue of alerts pending delivery
+const queueSize = 100
+
+// Engine evaluates the rules on every block and periodically, and sends an alert when a rule
+// starts matching and another when it is resolved. While a rule keeps matching it is not repeated.
+// It implements redt.BlockObserver and redt.StallObserver.
+type Engine struct {
+	cfg       *Config
+	rt        *redt.RedTNode
+	db        *history.Blockchain
+	notifiers []Notifier
+	queue     chan *Alert
+
+	mu                 sync.Mutex
+	firing             map[string]*Alert
+	consecutiveMissing map[common.Address]int64
+	lastBlockReceived  time.Time
+	lastBlockNumber    int64
+}
+
+// NewEngine creates the engine with the notifiers in the configuration
+func NewEngine(cfg *Config) (*Engine, error) {
+
+	e := &Engine{
+		cfg:                cfg,
+		queue:              make(chan *Alert, queueSize),
+		firing:             make(map[string]*Alert),
+		consecutiveMissing: make(map[common.Address]int64),
+	}
+
+	for _, nc := range cfg.Notifiers {
+		n, err := newNotifier(nc, cfg.SMTP)
+		if err != nil {
+			return nil, err
+		}
+		e.notifiers = append(e.notifiers, n)
+	}
+
+	// Alerts are delivered in the background, so slow notifiers do not delay block processing
+	go e.dispatch()
+
+	return e, nil
+}
+
+// NewEngineFromFile loads the rules file and creates the engine
+func NewEngineFromFile(path string) (*Engine, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewEngine(cfg)
+}
+
+// AddNotifier adds a destination for the alerts, in addition to the ones in the configuration
+func (e *Engine) AddNotifier(n Notifier) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.notifiers = append(e.notifiers, n)
+}
+
+// Active returns the alerts currently firing, oldest first
+func (e *Engine) Active() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	active := make([]Alert, 0, len(e.firing))
+	for _, a := range e.firing {
+		active = append(active, *a)
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].StartsAt.Before(active[j].StartsAt) })
+
+	return active
+}
+
+// SetHistory configures the database used for the daily digest, where the blocks processed are stored.
+// It must be called before Start.
+func (e *Engine) SetHistory(db *history.Blockchain) {
+	e.db = db
+}
+
+// Start is called when the engine is added as observer of the node. It starts the periodic checks.
+func (e *Engine) Start(rt *redt.RedTNode) {
+	e.mu.Lock()
+	e.rt = rt
+	e.mu.Unlock()
+
+	if e.cfg.Digest != nil {
+		go newDigest(e.cfg.Digest, e.cfg.SMTP, rt, e.db).run()
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(e.cfg.CheckInterval) * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			e.check()
+		}
+	}()
+}
+
+// BlockProcessed evaluates the rules that depend on the contents of blocks
+func (e *Engine) BlockProcessed(r *redt.BlockReport) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastBlockReceived = time.Now()
+	e.lastBlockNumber = r.Number
+
+	// Update the number of consecutive blocks that each validator did not sign
+	for _, val := range r.Missing {
+		e.consecutiveMissing[val.Address]++
+	}
+	for _, val := range r.Signers {
+		e.consecutiveMissing[val.Address] = 0
+	}
+
+	for i := range e.cfg.Rules {
+		rule := &e.cfg.Rules[i]
+
+		switch rule.Type {
+
+		case RuleNoBlock:
+			// A new block resolves the alert
+			e.resolve(rule, "")
+
+		case RuleBlockInterval:
+			if r.Interval == 0 {
+				continue
+			}
+			if int64(r.Interval) > rule.Seconds {
+				e.fire(rule, "", r.Number, fmt.Sprintf("Block %v took %v seconds, more than %v", r.Number, r.Interval, rule.Seconds))
+			} else {
+				e.resolve(rule, "")
+			}
+
+		case RuleMissingSeals:
+			for val, count := range e.consecutiveMissing {
+				if len(rule.Validator) > 0 && common.HexToAddress(rule.Validator) != val {
+					continue
+				}
+				if count >= rule.Blocks {
+					e.fire(rule, val.Hex(), r.Number, fmt.Sprintf("Validator %v missing from the committed seals of the last %v blocks", e.operatorName(val), count))
+				} else if count == 0 {
+					e.resolve(rule, val.Hex())
+				}
+			}
+
+		}
+	}
+}
+
+// StallChanged implements redt.StallObserver, firing the chain_stalled rules while the chain is stalled
+func (e *Engine) StallChanged(report *redt.StallReport) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range e.cfg.Rules {
+		rule := &e.cfg.Rules[i]
+		if rule.Type != RuleChainStalled {
+			continue
+		}
+		if report.Stalled {
+			e.fire(rule, "", report.LastBlock, report.String())
+		} else {
+			e.resolve(rule, "")
+		}
+	}
+}
+
+// check evaluates the rules that depend on time or on the state of the node
+func (e *Engine) check() {
+
+	// Get the state of the node before taking the lock, as it may take time
+	var behind int64
+	var behindErr error
+	e.mu.Lock()
+	rt := e.rt
+	e.mu.Unlock()
+	if rt != nil && e.hasRule(RuleNodeLagging) {
+		behind, behindErr = rt.BlocksBehind()
+		if behindErr != nil {
+			log.Error().Err(behindErr).Msg("checking if node is lagging")
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range e.cfg.Rules {
+		rule := &e.cfg.Rules[i]
+
+		switch rule.Type {
+
+		case RuleNoBlock:
+			if e.lastBlockReceived.IsZero() {
+				continue
+			}
+			elapsed := time.Since(e.lastBlockReceived)
+			if elapsed > rule.threshold() {
+				e.fire(rule, "", e.lastBlockNumber, fmt.Sprintf("No new block for %v seconds after block %v", int64(elapsed.Seconds()), e.lastBlockNumber))
+			}
+
+		case RuleNodeLagging:
+			if rt == nil || behindErr != nil {
+				continue
+			}
+			if behind > rule.Blocks {
+				e.fire(rule, "", e.lastBlockNumber, fmt.Sprintf("Node is %v blocks behind its peers", behind))
+			} else {
+				e.resolve(rule, "")
+			}
+
+		}
+	}
+}
+
+func (e *Engine) hasRule(ruleType string) bool {
+	for _, r := range e.cfg.Rules {
+		if r.Type == ruleType {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) operatorName(val common.Address) string {
+	if e.rt == nil {
+		return val.Hex()
+	}
+	if name := e.rt.OperatorName(val); len(name) > 0 {
+		return name
+	}
+	return val.Hex()
+}
+
+func alertKey(rule *RuleConfig, subject string) string {
+	return rule.Name + "/" + subject
+}
+
+// fire sends the alert unless it is already firing. Must be called with the lock held.
+func (e *Engine) fire(rule *RuleConfig, subject string, block int64, summary string) {
+
+	key := alertKey(rule, subject)
+	if _, ok := e.firing[key]; ok {
+		return
+	}
+
+	a := &Alert{
+		Rule:      rule.Name,
+		Type:      rule.Type,
+		Severity:  rule.Severity,
+		Status:    StatusFiring,
+		Validator: subject,
+		Summary:   summary,
+		Block:     block,
+		StartsAt:  time.Now(),
+	}
+	if len(subject) > 0 && e.rt != nil {
+		a.Operator = e.rt.OperatorName(common.HexToAddress(subject))
+	}
+
+	e.firing[key] = a
+	e.enqueue(a)
+}
+
+// resolve sends the resolution of the alert if it was firing. Must be called with the lock held.
+func (e *Engine) resolve(rule *RuleConfig, subject string) {
+
+	key := alertKey(rule, subject)
+	a, ok := e.firing[key]
+	if !ok {
+		return
+	}
+	delete(e.firing, key)
+
+	now := time.Now()
+	a.Status = StatusResolved
+	a.EndsAt = &now
+	e.enqueue(a)
+}
+
+func (e *Engine) enqueue(a *Alert) {
+	log.Info().Str("rule", a.Rule).Str("status", string(a.Status)).Msg(a.Summary)
+
+	// Send a copy, because the alert in the firing set will be modified when resolved
+	c := *a
+	select {
+	case e.queue <- &c:
+	default:
+		log.Error().Str("rule", a.Rule).Msg("alert queue full, notification dropped")
+	}
+}
+
+// SendTest delivers a test alert to all notifiers and waits for the result
+func (e *Engine) SendTest() error {
+	a := &Alert{
+		Rule:     "test",
+		Type:     "test",
+		Severity: SeverityCritical,
+		Status:   StatusFiring,
+		Summary:  "This is a test alert from signers",
+		StartsAt: time.Now(),
+	}
+
+	var failed []string
+	for _, n := range e.notifiers {
+		if err := n.Notify(a); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("sending test alert: %v", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func (e *Engine) dispatch() {
+	for a := range e.queue {
+
+		e.mu.Lock()
+		notifiers := e.notifiers
+		e.mu.Unlock()
+
+		for _, n := range notifiers {
+			if err := n.Notify(a); err != nil {
+				log.Error().Err(err).Str("rule", a.Rule).Msg("sending alert")
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/stretchr/testify/assert"
+)
+
+type chanNotifier chan *Alert
+
+func (n chanNotifier) Notify(a *Alert) error {
+	n <- a
+	return nil
+}
+
+func (n chanNotifier) next(t *testing.T) *Alert {
+	select {
+	case a := <-n:
+		return a
+	case <-time.After(time.Second):
+		t.Fatal("no alert received")
+	}
+	return nil
+}
+
+func (n chanNotifier) none(t *testing.T) {
+	select {
+	case a := <-n:
+		t.Fatalf("unexpected alert %v", a.Summary)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMissingSeals(t *testing.T) {
+	cfg := &Config{Rules: []RuleConfig{{Name: "not-signing", Type: RuleMissingSeals, Blocks: 3}}}
+	assert.NoError(t, cfg.validate())
+
+	e, err := NewEngine(cfg)
+	assert.NoError(t, err)
+	n := make(chanNotifier, 10)
+	e.AddNotifier(n)
+
+	good := common.HexToAddress("0x01")
+	bad := common.HexToAddress("0x02")
+
+	block := func(number int64, signers []common.Address, missing []common.Address) {
+		r := &redt.BlockReport{Number: number}
+		for _, addr := range signers {
+			r.Signers = append(r.Signers, redt.Validator{Address: addr})
+		}
+		for _, addr := range missing {
+			r.Missing = append(r.Missing, redt.Validator{Address: addr})
+		}
+		e.BlockProcessed(r)
+	}
+
+	// Two blocks missing are not enough
+	block(1, []common.Address{good}, []common.Address{bad})
+	block(2, []common.Address{good}, []common.Address{bad})
+	n.none(t)
+
+	// The third one fires
+	block(3, []common.Address{good}, []common.Address{bad})
+	a := n.next(t)
+	assert.Equal(t, StatusFiring, a.Status)
+	assert.Equal(t, bad.Hex(), a.Validator)
+	assert.Equal(t, SeverityWarning, a.Severity)
+
+	// It is not repeated while still missing
+	block(4, []common.Address{good}, []common.Address{bad})
+	n.none(t)
+	assert.Len(t, e.Active(), 1)
+
+	// And it is resolved when it signs again
+	block(5, []common.Address{good, bad}, nil)
+	a = n.next(t)
+	assert.Equal(t, StatusResolved, a.Status)
+	assert.NotNil(t, a.EndsAt)
+	assert.Empty(t, e.Active())
+}
+
+func TestBlockInterval(t *testing.T) {
+	cfg := &Config{Rules: []RuleConfig{{Name: "slow", Type: RuleBlockInterval, Seconds: 10, Severity: SeverityCritical}}}
+	assert.NoError(t, cfg.validate())
+
+	e, err := NewEngine(cfg)
+	assert.NoError(t, err)
+	n := make(chanNotifier, 10)
+	e.AddNotifier(n)
+
+	e.BlockProcessed(&redt.BlockReport{Number: 1, Interval: 3})
+	n.none(t)
+
+	e.BlockProcessed(&redt.BlockReport{Number: 2, Interval: 12})
+	assert.Equal(t, StatusFiring, n.next(t).Status)
+
+	e.BlockProcessed(&redt.BlockReport{Number: 3, Interval: 3})
+	assert.Equal(t, StatusResolved, n.next(t).Status)
+}
+
+func TestChainStalled(t *testing.T) {
+	cfg := &Config{Rules: []RuleConfig{{Name: "stalled", Type: RuleChainStalled, Severity: SeverityCritical}}}
+	assert.NoError(t, cfg.validate())
+
+	e, err := NewEngine(cfg)
+	assert.NoError(t, err)
+	n := make(chanNotifier, 10)
+	e.AddNotifier(n)
+
+	report := &redt.StallReport{
+		Stalled:   true,
+		LastBlock: 100,
+		Elapsed:   45,
+		Liveness: &redt.Liveness{
+			Validators: []redt.ValidatorLiveness{{Operator: "Alpha", Connected: true}, {Operator: "Bravo"}},
+			Live:       1,
+			Quorum:     2,
+		},
+	}
+	e.StallChanged(report)
+	a := n.next(t)
+	assert.Equal(t, StatusFiring, a.Status)
+	assert.Equal(t, int64(100), a.Block)
+	assert.Equal(t, "Chain stalled for 45s after block 100: 1 of 2 validators connected, quorum of 2 NOT reachable (not connected: Bravo)", a.Summary)
+
+	// The refreshes while stalled are not repeated
+	e.StallChanged(report)
+	n.none(t)
+
+	e.StallChanged(&redt.StallReport{LastBlock: 101})
+	assert.Equal(t, StatusResolved, n.next(t).Status)
+}
+
+func TestValidate(t *testing.T) {
+	cfg := &Config{Rules: []RuleConfig{{Type: RuleNoBlock}}}
+	assert.Error(t, cfg.validate())
+
+	cfg = &Config{Rules: []RuleConfig{{Type: "unknown", Seconds: 1}}}
+	assert.Error(t, cfg.validate())
+
+	cfg = &Config{Rules: []RuleConfig{{Type: RuleNoBlock, Seconds: 30}}}
+	assert.NoError(t, cfg.validate())
+	assert.Equal(t, RuleNoBlock, cfg.Rules[0].Name)
+	assert.Equal(t, defaultCheckInterval, cfg.CheckInterval)
+}
@@ -0,0 +1,103 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Timeout for the delivery of a notification
+const notifyTimeout = 10 * time.Second
+
+func newNotifier(nc NotifierConfig, smtpConfig *SMTPConfig) (Notifier, error) {
+	switch nc.Type {
+	case "email":
+		return &EmailNotifier{SMTP: smtpConfig, To: nc.To, MinSeverity: nc.MinSeverity}, nil
+	case "webhook":
+		return &WebhookNotifier{URL: nc.URL, Headers: nc.Headers}, nil
+	case "slack", "mattermost":
+		return &SlackNotifier{URL: nc.URL, Channel: nc.Channel, Username: nc.Username}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier type %q", nc.Type)
+}
+
+// WebhookNotifier posts the alert as JSON to a generic webhook
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string
+}
+
+func (n *WebhookNotifier) Notify(a *Alert) error {
+	return postJSON(n.URL, n.Headers, a)
+}
+
+// SlackNotifier posts the alert to an incoming webhook of Slack, or of Mattermost
+// which accepts the same payload
+type SlackNotifier struct {
+	URL      string
+	Channel  string
+	Username string
+}
+
+type slackPayload struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+func (n *SlackNotifier) Notify(a *Alert) error {
+	payload := slackPayload{
+		Text:     FormatText(a),
+		Channel:  n.Channel,
+		Username: n.Username,
+	}
+	return postJSON(n.URL, nil, payload)
+}
+
+// FormatText returns a short human readable description of the alert
+func FormatText(a *Alert) string {
+	icon := ":rotating_light:"
+	switch {
+	case a.Status == StatusResolved:
+		icon = ":white_check_mark:"
+	case a.Severity == SeverityWarning:
+		icon = ":warning:"
+	case a.Severity == SeverityInfo:
+		icon = ":information_source:"
+	}
+
+	return fmt.Sprintf("%v [%v] %v (%v): %v", icon, strings.ToUpper(string(a.Status)), a.Rule, a.Severity, a.Summary)
+}
+
+func postJSON(url string, headers map[string]string, body any) error {
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: notifyTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %v returned status %v", url, resp.Status)
+	}
+
+	return nil
+}
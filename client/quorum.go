@@ -43,8 +43,8 @@ func NewQuorumClient(rawUrl string) (*QuorumClient, error) {
 	}
 
 	// Start websocket receiver.
+	c.shutdownWg.Add(1)
 	go func() {
-		c.shutdownWg.Add(1)
 		c.wsClient.listen(c.shutdownChan)
 		c.shutdownWg.Done()
 	}()
@@ -57,6 +57,11 @@ func (qc *QuorumClient) SubscribeChainHead(ch chan<- types.RawHeader) error {
 	return qc.wsClient.subscribeChainHead(ch)
 }
 
+// Connected reports whether the WebSocket connection to the node is currently established.
+func (qc *QuorumClient) Connected() bool {
+	return qc.wsClient.connected()
+}
+
 // Execute customized rpc call.
 func (qc *QuorumClient) RPCCall(result interface{}, method string, args ...interface{}) error {
 	return qc.rpcCall(time.Second, result, method, args)
@@ -81,6 +81,12 @@ func (c *webSocketClient) dial(rawUrl string) error {
 	return nil
 }
 
+func (c *webSocketClient) connected() bool {
+	c.connMux.Lock()
+	defer c.connMux.Unlock()
+	return c.conn != nil
+}
+
 // subscribe header
 func (c *webSocketClient) subscribeChainHead(ch chan<- types.RawHeader) error {
 	c.connMux.Lock()
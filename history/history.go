@@ -9,6 +9,7 @@ import (
 	"database/sql"
 
 	_ "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/hesusruiz/signers/redt"
 	"github.com/labstack/gommon/log"
@@ -25,7 +26,7 @@ var blockchainTableCreateStmt = `
 CREATE TABLE IF NOT EXISTS blockchain (
   Number        INTEGER PRIMARY KEY,
   Proposer      TEXT,
-  ProposerCount INTEGER,
+  ProposerCount INTEGER, -- Not used, always zero
   GasLimit      INTEGER,
   GasUsed       INTEGER,
   Time          INTEGER,
@@ -49,8 +50,8 @@ var signersTableCreateStmt = `
 CREATE TABLE IF NOT EXISTS signers (
   Number      INTEGER,
   Address     TEXT,
-  AsProposer  INTEGER,
-  AsSigner    INTEGER
+  AsProposer  INTEGER, -- Not used, always zero. The proposer is in the blockchain table
+  AsSigner    INTEGER  -- Not used, always zero. The rows are the signers of the block
 );`
 
 // Dropping the table
@@ -59,6 +60,46 @@ var signersTableDropStmt = `DROP TABLE IF EXISTS signers`
 // Insert a record into the table
 var signersTableInsertRecordStmt = `INSERT INTO signers VALUES (?, ?, ?, ?)`
 
+// Index to speed up the statistics of a given signer
+var signersTableIndexStmt = `CREATE INDEX IF NOT EXISTS signers_address ON signers (Address, Number)`
+
+// **************************************
+// The Ranges table
+// **************************************
+
+// Each record is a contiguous range of blocks already stored in the blockchain table.
+// It is the gap metadata used to avoid downloading blocks twice.
+var rangesTableCreateStmt = `
+CREATE TABLE IF NOT EXISTS ranges (
+  StartNumber INTEGER PRIMARY KEY,
+  EndNumber   INTEGER
+);`
+
+// Insert a record into the table
+var rangesTableInsertRecordStmt = `INSERT INTO ranges VALUES (?, ?)`
+
+// Rebuild the ranges from the blocks stored, for databases created before the ranges table existed
+var rangesFromBlockchainStmt = `
+SELECT MIN(Number), MAX(Number) FROM (
+  SELECT Number, Number - ROW_NUMBER() OVER (ORDER BY Number) AS Island FROM blockchain
+) GROUP BY Island ORDER BY MIN(Number)`
+
+// **************************************
+// The versions of the schema
+// **************************************
+
+// The version of the schema, stored in the user_version of the database
+const schemaVersion = 1
+
+// Each migration upgrades the schema from the version of its index to the next one
+var migrations = []string{
+
+	// Before version 1 some releases stored counters in the columns which are not used, with
+	// different meanings. They are reset, so all the rows of a database have the same values.
+	`UPDATE blockchain SET ProposerCount = 0;
+	 UPDATE signers SET AsProposer = 0, AsSigner = 0;`,
+}
+
 type Blockchain struct {
 	db                            *sql.DB
 	tx                            *sql.Tx
@@ -101,6 +142,27 @@ func Open(name string) (b *Blockchain, err error) {
 		return nil, err
 	}
 
+	// Create the index for the signers table
+	err = openOrCreateTable(db, signersTableIndexStmt)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	// Create the ranges table
+	err = openOrCreateTable(db, rangesTableCreateStmt)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	// Upgrade the tables of older databases
+	err = migrate(db)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
 	b = &Blockchain{
 		db: db,
 	}
@@ -122,11 +184,49 @@ func openOrCreateTable(db *sql.DB, st string) error {
 	return nil
 }
 
+// migrate runs the migrations from the version of the database to the current one, in a transaction
+func migrate(db *sql.DB) error {
+
+	var version int
+	err := db.QueryRow("PRAGMA user_version").Scan(&version)
+	if err != nil {
+		return err
+	}
+	if version >= schemaVersion {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range migrations[version:schemaVersion] {
+		if _, err = tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// The pragma does not accept parameters
+	if _, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Close closes the database
+func (b *Blockchain) Close() error {
+	return b.db.Close()
+}
+
 func (b *Blockchain) MinBlockNumber() (int64, error) {
 
 	var number int64
 
-	err := b.db.QueryRow("SELECT MIN(number) FROM blockchain").Scan(&number)
+	err := b.db.QueryRow("SELECT COALESCE(MIN(number), 0) FROM blockchain").Scan(&number)
 	if err != nil {
 		log.Error(err)
 		return 0, err
@@ -139,7 +239,7 @@ func (b *Blockchain) MaxBlockNumber() (int64, error) {
 
 	var number int64
 
-	err := b.db.QueryRow("SELECT MAX(number) FROM blockchain").Scan(&number)
+	err := b.db.QueryRow("SELECT COALESCE(MAX(number), 0) FROM blockchain").Scan(&number)
 	if err != nil {
 		log.Error(err)
 		return 0, err
@@ -161,6 +261,117 @@ func (b *Blockchain) TimestampForNumber(number int64) (int64, error) {
 	return timestamp, nil
 }
 
+// SignerStats are the activity counters of a validator in a range of blocks
+type SignerStats struct {
+	Address   string `json:"address"`
+	From      int64  `json:"from"`
+	To        int64  `json:"to"`
+	Blocks    int64  `json:"blocks"`
+	Proposals int64  `json:"proposals"`
+	Seals     int64  `json:"seals"`
+}
+
+// SignerStatsForRange calculates the activity of the validator in the blocks stored between from and to.
+// Blocks is the number of blocks in the database for the range, which may be less than the full range.
+func (b *Blockchain) SignerStatsForRange(address string, from int64, to int64) (*SignerStats, error) {
+
+	stats := &SignerStats{
+		Address: address,
+		From:    from,
+		To:      to,
+	}
+
+	err := b.db.QueryRow(
+		"SELECT COUNT(*), COUNT(CASE WHEN proposer=? THEN 1 END) FROM blockchain WHERE number BETWEEN ? AND ?",
+		address, from, to).Scan(&stats.Blocks, &stats.Proposals)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	err = b.db.QueryRow(
+		"SELECT COUNT(*) FROM signers WHERE address=? AND number BETWEEN ? AND ?",
+		address, from, to).Scan(&stats.Seals)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// StatsForPeriod calculates the activity of all signers in the blocks stored with a timestamp
+// in the interval [from, to), in Unix seconds. It also returns the number of blocks in the interval.
+func (b *Blockchain) StatsForPeriod(from int64, to int64) (int64, []SignerStats, error) {
+
+	var numBlocks int64
+	var firstBlock, lastBlock int64
+
+	err := b.db.QueryRow(
+		"SELECT COUNT(*), COALESCE(MIN(number), 0), COALESCE(MAX(number), 0) FROM blockchain WHERE time >= ? AND time < ?",
+		from, to).Scan(&numBlocks, &firstBlock, &lastBlock)
+	if err != nil {
+		log.Error(err)
+		return 0, nil, err
+	}
+
+	statsByAddress := map[string]*SignerStats{}
+	getStats := func(address string) *SignerStats {
+		st := statsByAddress[address]
+		if st == nil {
+			st = &SignerStats{Address: address, From: firstBlock, To: lastBlock, Blocks: numBlocks}
+			statsByAddress[address] = st
+		}
+		return st
+	}
+
+	// The proposals
+	err = b.queryCounts(func(address string, count int64) { getStats(address).Proposals = count },
+		"SELECT proposer, COUNT(*) FROM blockchain WHERE time >= ? AND time < ? GROUP BY proposer",
+		from, to)
+	if err != nil {
+		log.Error(err)
+		return 0, nil, err
+	}
+
+	// The seals
+	err = b.queryCounts(func(address string, count int64) { getStats(address).Seals = count },
+		"SELECT s.address, COUNT(*) FROM signers s JOIN blockchain b ON s.number = b.number WHERE b.time >= ? AND b.time < ? GROUP BY s.address",
+		from, to)
+	if err != nil {
+		log.Error(err)
+		return 0, nil, err
+	}
+
+	stats := make([]SignerStats, 0, len(statsByAddress))
+	for _, st := range statsByAddress {
+		stats = append(stats, *st)
+	}
+
+	return numBlocks, stats, nil
+}
+
+// queryCounts runs a query returning rows of (address, count) and calls fn for each row
+func (b *Blockchain) queryCounts(fn func(address string, count int64), query string, args ...any) error {
+
+	rows, err := b.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address string
+		var count int64
+		if err = rows.Scan(&address, &count); err != nil {
+			return err
+		}
+		fn(address, count)
+	}
+
+	return rows.Err()
+}
+
 // Begin starts a new transaction
 func (b *Blockchain) Begin() error {
 
@@ -176,6 +387,7 @@ func (b *Blockchain) Begin() error {
 	stmt, err := b.tx.Prepare(blockchainTableInsertRecordStmt)
 	if err != nil {
 		log.Error(err)
+		b.Rollback()
 		return err
 	}
 	b.blockchainTableInsertPrepared = stmt
@@ -183,6 +395,7 @@ func (b *Blockchain) Begin() error {
 	stmt, err = b.tx.Prepare(signersTableInsertRecordStmt)
 	if err != nil {
 		log.Error(err)
+		b.Rollback()
 		return err
 	}
 	b.signersTableInsertPrepared = stmt
@@ -192,16 +405,41 @@ func (b *Blockchain) Begin() error {
 
 func (b *Blockchain) Commit() error {
 	err := b.tx.Commit()
+	b.tx = nil
+	b.closeStatements()
 	if err != nil {
 		log.Error(err)
 		return err
 	}
-	b.blockchainTableInsertPrepared.Close()
-	b.signersTableInsertPrepared.Close()
 	return nil
 }
 
-func (b *Blockchain) InsertHeader(h *types.Header, signers *redt.SignerData, numtxs uint64) error {
+// Rollback discards the current transaction. It does nothing if there is no transaction.
+func (b *Blockchain) Rollback() error {
+	if b.tx == nil {
+		return nil
+	}
+	err := b.tx.Rollback()
+	b.tx = nil
+	b.closeStatements()
+	return err
+}
+
+// closeStatements closes the statements prepared for the transaction, if they were prepared
+func (b *Blockchain) closeStatements() {
+	if b.blockchainTableInsertPrepared != nil {
+		b.blockchainTableInsertPrepared.Close()
+		b.blockchainTableInsertPrepared = nil
+	}
+	if b.signersTableInsertPrepared != nil {
+		b.signersTableInsertPrepared.Close()
+		b.signersTableInsertPrepared = nil
+	}
+}
+
+// InsertHeader stores the block with its report: the proposer and a row for each signer.
+// The columns which are not used are zero, as the counters of the report are not stored.
+func (b *Blockchain) InsertHeader(h *types.Header, report *redt.BlockReport, numtxs uint64) error {
 
 	// Number        INTEGER PRIMARY KEY,
 	// Proposer      TEXT,
@@ -216,7 +454,7 @@ func (b *Blockchain) InsertHeader(h *types.Header, signers *redt.SignerData, num
 
 	_, err := b.blockchainTableInsertPrepared.Exec(
 		h.Number.Uint64(),
-		signers.Proposer,
+		report.Proposer.Address.String(),
 		0,
 		h.GasLimit,
 		h.GasUsed,
@@ -231,7 +469,7 @@ func (b *Blockchain) InsertHeader(h *types.Header, signers *redt.SignerData, num
 		return err
 	}
 
-	for _, address := range signers.Signers {
+	for _, signer := range report.Signers {
 		// Number      INTEGER PRIMARY KEY,
 		// Address     TEXT,
 		// AsProposer  INTEGER,
@@ -239,7 +477,7 @@ func (b *Blockchain) InsertHeader(h *types.Header, signers *redt.SignerData, num
 
 		_, err = b.signersTableInsertPrepared.Exec(
 			h.Number.Uint64(),
-			address,
+			signer.Address.String(),
 			0,
 			0,
 		)
@@ -253,9 +491,63 @@ func (b *Blockchain) InsertHeader(h *types.Header, signers *redt.SignerData, num
 	return nil
 }
 
-// GetBlockForNumber gets a block with specified number ither from the database or from the network
-// It updates de database if the block is not there
-func (b *Blockchain) SignerDataForBlockNumberCached(number int64) (*types.Header, *redt.SignerData, error) {
+// StoreBlock stores a block processed by the statistics and records it in the ranges stored,
+// so the history and the digest include the blocks received by the server. It does nothing if
+// the block is already stored.
+func (b *Blockchain) StoreBlock(header *types.Header, report *redt.BlockReport) error {
+
+	// The call is serialised across goroutines
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var count int
+	err := b.db.QueryRow("SELECT COUNT(*) FROM blockchain WHERE number=?", report.Number).Scan(&count)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return b.storeBlock(header, report)
+}
+
+// storeBlock inserts the block and records it in the ranges stored, in the same transaction
+func (b *Blockchain) storeBlock(header *types.Header, report *redt.BlockReport) error {
+
+	// Start a db transaction
+	err := b.Begin()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	// Insert the record in the db, and record it in the ranges stored
+	err = b.InsertHeader(header, report, 0)
+	if err == nil {
+		err = b.AddRange(BlockRange{Start: report.Number, End: report.Number})
+	}
+	if err != nil {
+		log.Error(err)
+		b.Rollback()
+		return err
+	}
+
+	// Commit the transaction
+	err = b.Commit()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ReportForBlockNumberCached gets the report of a block with specified number either from the database or from the network.
+// It updates de database if the block is not there. The reports built from the database only have the
+// data stored there: the number, time, gas, proposer and signers.
+func (b *Blockchain) ReportForBlockNumberCached(number int64) (*types.Header, *redt.BlockReport, error) {
 
 	// The call is serialised across goroutines
 	b.mu.Lock()
@@ -277,10 +569,9 @@ func (b *Blockchain) SignerDataForBlockNumberCached(number int64) (*types.Header
 
 	// Check if block is in the database
 	var proposer string
-	var proposercount int64
 	var gaslimit, gasused, timestamp uint64
 
-	err := b.db.QueryRow("SELECT proposer, proposercount, gaslimit, gasused, time FROM blockchain WHERE number=?", number).Scan(&proposer, &proposercount, &gaslimit, &gasused, &timestamp)
+	err := b.db.QueryRow("SELECT proposer, gaslimit, gasused, time FROM blockchain WHERE number=?", number).Scan(&proposer, &gaslimit, &gasused, &timestamp)
 	if err != nil && err != sql.ErrNoRows {
 		log.Error(err)
 		return nil, nil, err
@@ -290,36 +581,19 @@ func (b *Blockchain) SignerDataForBlockNumberCached(number int64) (*types.Header
 	if err == sql.ErrNoRows {
 
 		// Get the block data from network
-		header, signers, err := b.rt.SignerDataForBlockNumber(number)
+		header, report, err := b.rt.ReportForBlockNumber(number)
 		if err != nil {
 			log.Error(err)
 			return nil, nil, err
 		}
 
-		// Start a db transaction
-		err = b.Begin()
+		err = b.storeBlock(header, report)
 		if err != nil {
-			log.Error(err)
-			return nil, nil, err
-		}
-		defer b.db.Close()
-
-		// Insert the record in the db
-		err = b.InsertHeader(header, signers, 0)
-		if err != nil {
-			log.Error(err)
-			return nil, nil, err
-		}
-
-		// Commit the transaction
-		err = b.Commit()
-		if err != nil {
-			log.Error(err)
 			return nil, nil, err
 		}
 
 		// Return the data to the caller
-		return header, signers, nil
+		return header, report, nil
 
 	}
 
@@ -332,8 +606,15 @@ func (b *Blockchain) SignerDataForBlockNumberCached(number int64) (*types.Header
 	header.GasLimit = gaslimit
 	header.GasUsed = gasused
 
-	signers := &redt.SignerData{}
-	signers.Proposer = proposer
+	report := &redt.BlockReport{
+		Number:    number,
+		Timestamp: timestamp,
+		Time:      time.Unix(int64(timestamp), 0).UTC(),
+		GasLimit:  gaslimit,
+		GasUsed:   gasused,
+		Proposer:  redt.Validator{Address: common.HexToAddress(proposer)},
+		Missing:   []redt.Validator{},
+	}
 
 	// CREATE TABLE IF NOT EXISTS signers (
 	// 	Number      INTEGER,
@@ -350,7 +631,7 @@ func (b *Blockchain) SignerDataForBlockNumberCached(number int64) (*types.Header
 	}
 	defer rows.Close()
 
-	sgs := make([]string, 0)
+	sgs := make([]redt.Validator, 0)
 
 	// Retrieve all signers
 	for rows.Next() {
@@ -362,10 +643,10 @@ func (b *Blockchain) SignerDataForBlockNumberCached(number int64) (*types.Header
 			log.Error(err)
 			return nil, nil, err
 		}
-		sgs = append(sgs, address)
+		sgs = append(sgs, redt.Validator{Address: common.HexToAddress(address)})
 
 	}
-	signers.Signers = sgs
+	report.Signers = sgs
 
 	// Ensure to check for Close errors that may be returned from the driver. The query may
 	// encounter an auto-commit error and be forced to rollback changes.
@@ -380,7 +661,7 @@ func (b *Blockchain) SignerDataForBlockNumberCached(number int64) (*types.Header
 		return nil, nil, err
 	}
 
-	return header, signers, nil
+	return header, report, nil
 
 }
 
@@ -427,6 +708,15 @@ func showStats(dsn string) error {
 
 	fmt.Printf("%v blocks from %v (%v) to %v (%v)\n", maxNumber-minNumber, minNumber, mint, maxNumber, maxt)
 
+	// Display the ranges stored and the gaps between them
+	ranges, err := blk.StoredRanges()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	fmt.Println("Stored ranges:", ranges)
+	fmt.Println("Gaps:", missingRanges(ranges, minNumber, maxNumber))
+
 	return nil
 
 }
@@ -454,8 +744,8 @@ func HistoryForward(url string, dsn string, stats bool) error {
 	}
 	defer blk.db.Close()
 
-	// Get the maximum block number in the database
-	maxNumber, err := blk.MaxBlockNumber()
+	// Get the ranges of blocks already in the database
+	ranges, err := blk.StoredRanges()
 	if err != nil {
 		log.Error(err)
 		return err
@@ -468,66 +758,23 @@ func HistoryForward(url string, dsn string, stats bool) error {
 	}
 
 	// If the database is empty, start from the current blockchain number upwards
-	if maxNumber == 0 {
+	var maxNumber int64
+	if len(ranges) == 0 {
 
 		startNumber = currentNumber
 
 	} else {
 
 		// We will start from the maximum number not yet registered + 1
+		maxNumber = ranges[len(ranges)-1].End
 		startNumber = maxNumber + 1
 
 	}
 
-	// We perform a Commit every 100 insertions, this is the counter
-	count := 0
-	const insertsPerCommit = 100
-
-	// Start a db transaction
-	err = blk.Begin()
-	if err != nil {
-		return err
-	}
-
 	fmt.Printf("Current block: %v Max db block: %v, Start block: %v\n", currentNumber, maxNumber, startNumber)
 
 	// Loop until the current block (at this time)
-	for i := startNumber; i <= currentNumber; i++ {
-
-		// Get the block data
-		header, signers, err := rt.SignerDataForBlockNumber(i)
-		if err != nil {
-			return err
-		}
-
-		// Insert
-		err = blk.InsertHeader(header, signers, 0)
-		if err != nil {
-			log.Error(err)
-			return err
-		}
-
-		count++
-
-		// Commit if enough insertions have been made
-		if count >= insertsPerCommit {
-			fmt.Println("Block ", i)
-
-			// Commit and start a new transaction
-			blk.Commit()
-			err = blk.Begin()
-			if err != nil {
-				return err
-			}
-			count = 0
-		}
-
-	}
-
-	// Commit the last set of inserts
-	blk.Commit()
-
-	return nil
+	return downloadRanges(rt, blk, missingRanges(ranges, startNumber, currentNumber), false)
 }
 
 func HistoryBackwards(url string, dsn string, stats bool) error {
@@ -553,14 +800,15 @@ func HistoryBackwards(url string, dsn string, stats bool) error {
 	}
 	defer blk.db.Close()
 
-	numRecords, err := blk.MaxBlockNumber()
+	// Get the ranges of blocks already in the database
+	ranges, err := blk.StoredRanges()
 	if err != nil {
 		log.Error(err)
 		return err
 	}
 
 	// If the database is empty, start from the current blockchain number downwards
-	if numRecords == 0 {
+	if len(ranges) == 0 {
 
 		// Get the current block number in the blockchain
 		startNumber, err = rt.CurrentBlockNumber()
@@ -570,69 +818,78 @@ func HistoryBackwards(url string, dsn string, stats bool) error {
 
 	} else {
 
-		// We will start from the lowest number not yet registered
-		minNumber, err := blk.MinBlockNumber()
-		if err != nil {
-			log.Error(err)
-			return err
-		}
-
-		// Check if we have nothing to do
-		if minNumber == 0 {
-			return errors.New("lowest block number is zero")
-		}
+		// We will start from the highest number registered, skipping the blocks already stored,
+		// so any gap left by a previous download of an arbitrary range is also filled
+		startNumber = ranges[len(ranges)-1].End
 
-		// Set the start number to one lower
-		startNumber = minNumber - 1
+	}
 
+	// Check if we have nothing to do
+	gaps := missingRanges(ranges, 0, startNumber)
+	if len(gaps) == 0 {
+		return errors.New("all blocks down to genesis are already stored")
 	}
 
-	// We perform a Commit every 100 insertions, this is the counter
-	count := 0
-	const insertsPerCommit = 100
+	fmt.Println("Start:", gaps[len(gaps)-1].End)
 
-	// Start a db transaction
-	err = blk.Begin()
-	if err != nil {
-		return err
+	// Reverse the gaps, so we go from the most recent towards the genesis block
+	for i, j := 0, len(gaps)-1; i < j; i, j = i+1, j-1 {
+		gaps[i], gaps[j] = gaps[j], gaps[i]
 	}
 
-	fmt.Println("Start:", startNumber)
 	// Loop until the genesis block
-	for i := startNumber; i >= 0; i-- {
+	return downloadRanges(rt, blk, gaps, true)
+}
 
-		// Get the block data
-		header, signers, err := rt.SignerDataForBlockNumber(i)
-		if err != nil {
-			return err
-		}
+// HistoryRange downloads into the database the blocks between from and to (both included),
+// skipping the ones already stored. A negative value of to means the current block.
+func HistoryRange(url string, dsn string, from int64, to int64) error {
 
-		// Insert
-		err = blk.InsertHeader(header, signers, 0)
-		if err != nil {
-			log.Error(err)
-			return err
-		}
+	// Connect to the RedT node
+	rt, err := redt.NewRedTNode(url)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
 
-		count++
+	// Get the current block number in the blockchain
+	currentNumber, err := rt.CurrentBlockNumber()
+	if err != nil {
+		return err
+	}
 
-		// Commit if enough insertions have been made
-		if count >= insertsPerCommit {
-			fmt.Println("Block ", i)
+	// Check the range requested
+	if to < 0 {
+		to = currentNumber
+	}
+	if from < 0 || from > to {
+		return fmt.Errorf("invalid block range %v-%v", from, to)
+	}
+	if to > currentNumber {
+		return fmt.Errorf("block %v is above the current block %v", to, currentNumber)
+	}
 
-			// Commit and start a new transaction
-			blk.Commit()
-			err = blk.Begin()
-			if err != nil {
-				return err
-			}
-			count = 0
-		}
+	// Open the database
+	blk, err := Open(dsn)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer blk.db.Close()
 
+	// Get the ranges of blocks already in the database
+	ranges, err := blk.StoredRanges()
+	if err != nil {
+		log.Error(err)
+		return err
 	}
 
-	// Commit the last set of inserts
-	blk.Commit()
+	// Calculate what we do not have yet
+	gaps := missingRanges(ranges, from, to)
+	if len(gaps) == 0 {
+		fmt.Printf("Blocks %v-%v are already stored\n", from, to)
+		return nil
+	}
 
-	return nil
+	return downloadRanges(rt, blk, gaps, false)
 }
@@ -0,0 +1,263 @@
+package history
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeRange(t *testing.T) {
+	ranges := []BlockRange{{Start: 10, End: 20}, {Start: 40, End: 50}}
+
+	// Disjoint range goes in the middle
+	assert.Equal(t,
+		[]BlockRange{{Start: 10, End: 20}, {Start: 25, End: 30}, {Start: 40, End: 50}},
+		mergeRange(ranges, BlockRange{Start: 25, End: 30}))
+
+	// Adjacent ranges are joined
+	assert.Equal(t,
+		[]BlockRange{{Start: 10, End: 30}, {Start: 40, End: 50}},
+		mergeRange(ranges, BlockRange{Start: 21, End: 30}))
+
+	// A range overlapping two others joins all of them
+	assert.Equal(t,
+		[]BlockRange{{Start: 5, End: 60}},
+		mergeRange(ranges, BlockRange{Start: 5, End: 60}))
+}
+
+func TestMissingRanges(t *testing.T) {
+	ranges := []BlockRange{{Start: 10, End: 20}, {Start: 40, End: 50}}
+
+	assert.Equal(t,
+		[]BlockRange{{Start: 0, End: 9}, {Start: 21, End: 39}, {Start: 51, End: 60}},
+		missingRanges(ranges, 0, 60))
+
+	assert.Equal(t,
+		[]BlockRange{{Start: 21, End: 39}},
+		missingRanges(ranges, 15, 45))
+
+	assert.Empty(t, missingRanges(ranges, 12, 18))
+
+	assert.Equal(t,
+		[]BlockRange{{Start: 0, End: 100}},
+		missingRanges(nil, 0, 100))
+}
+
+func TestStoredRanges(t *testing.T) {
+	blk, err := Open(filepath.Join(t.TempDir(), "test.sqlite"))
+	assert.NoError(t, err)
+	defer blk.db.Close()
+
+	// Insert blocks without recording the ranges, as older versions did
+	assert.NoError(t, blk.Begin())
+	for _, number := range []int64{1, 2, 3, 7, 8} {
+		header := &types.Header{Number: big.NewInt(number)}
+		assert.NoError(t, blk.InsertHeader(header, &redt.BlockReport{}, 0))
+	}
+	assert.NoError(t, blk.Commit())
+
+	// The ranges are rebuilt from the blocks table
+	ranges, err := blk.StoredRanges()
+	assert.NoError(t, err)
+	assert.Equal(t, []BlockRange{{Start: 1, End: 3}, {Start: 7, End: 8}}, ranges)
+
+	// And new ranges are merged with them
+	assert.NoError(t, blk.AddRange(BlockRange{Start: 4, End: 6}))
+	ranges, err = blk.StoredRanges()
+	assert.NoError(t, err)
+	assert.Equal(t, []BlockRange{{Start: 1, End: 8}}, ranges)
+}
+
+func TestInterruptedDownload(t *testing.T) {
+	blk, err := Open(filepath.Join(t.TempDir(), "test.sqlite"))
+	assert.NoError(t, err)
+	defer blk.db.Close()
+
+	insert := func(from, to int64) {
+		for number := from; number <= to; number++ {
+			header := &types.Header{Number: big.NewInt(number)}
+			assert.NoError(t, blk.InsertHeader(header, &redt.BlockReport{}, 0))
+		}
+		assert.NoError(t, blk.AddRange(BlockRange{Start: from, End: to}))
+	}
+
+	// A batch interrupted before the commit leaves neither the blocks nor the range
+	assert.NoError(t, blk.Begin())
+	insert(1, 3)
+	assert.NoError(t, blk.Rollback())
+
+	// Rolling back again, as the deferred rollback of a failed download does, does nothing
+	assert.NoError(t, blk.Rollback())
+	ranges, err := blk.StoredRanges()
+	assert.NoError(t, err)
+	assert.Empty(t, ranges)
+
+	// So the blocks can be downloaded again
+	assert.NoError(t, blk.Begin())
+	insert(1, 3)
+	assert.NoError(t, blk.Commit())
+
+	// The blocks stored with ranges already recorded are merged in the same transaction
+	assert.NoError(t, blk.Begin())
+	insert(5, 5)
+	assert.NoError(t, blk.Commit())
+
+	ranges, err = blk.StoredRanges()
+	assert.NoError(t, err)
+	assert.Equal(t, []BlockRange{{Start: 1, End: 3}, {Start: 5, End: 5}}, ranges)
+}
+
+func TestSeries(t *testing.T) {
+	blk, err := Open(filepath.Join(t.TempDir(), "test.sqlite"))
+	assert.NoError(t, err)
+	defer blk.db.Close()
+
+	a := "0x1111111111111111111111111111111111111111"
+	b := "0x2222222222222222222222222222222222222222"
+
+	// Blocks 1-4 every 5 seconds, and block 10 after a gap
+	blocks := []struct {
+		number   int64
+		time     uint64
+		proposer string
+		signers  []string
+	}{
+		{1, 100, a, []string{a, b}},
+		{2, 105, b, []string{a, b}},
+		{3, 110, a, []string{a}},
+		{4, 115, b, []string{b}},
+		{10, 150, a, []string{a, b}},
+	}
+
+	assert.NoError(t, blk.Begin())
+	for _, bl := range blocks {
+		header := &types.Header{Number: big.NewInt(bl.number), Time: bl.time, GasUsed: 10, GasLimit: 100}
+		report := &redt.BlockReport{Proposer: redt.Validator{Address: common.HexToAddress(bl.proposer)}}
+		for _, signer := range bl.signers {
+			report.Signers = append(report.Signers, redt.Validator{Address: common.HexToAddress(signer)})
+		}
+		assert.NoError(t, blk.InsertHeader(header, report, 0))
+	}
+	assert.NoError(t, blk.Commit())
+
+	points, err := blk.BlockSeries(100, 200, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, []BlockSeriesPoint{
+		{Time: 100, Blocks: 4, AvgInterval: 5, MaxInterval: 5, GasUsed: 40, GasLimit: 400},
+		{Time: 150, Blocks: 1, GasUsed: 10, GasLimit: 100},
+	}, points)
+
+	signers, err := blk.SignerSeries(100, 200, 50, nil)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []SignerSeriesPoint{
+		{Time: 100, Address: a, Proposals: 2, Seals: 3},
+		{Time: 100, Address: b, Proposals: 2, Seals: 3},
+		{Time: 150, Address: a, Proposals: 1, Seals: 1},
+		{Time: 150, Address: b, Seals: 1},
+	}, signers)
+
+	// With a third validator c, it missed its turn after each block of b, but only the blocks
+	// with the previous one stored are known
+	c := "0x3333333333333333333333333333333333333333"
+	valSet := []common.Address{common.HexToAddress(a), common.HexToAddress(b), common.HexToAddress(c)}
+	signers, err = blk.SignerSeries(100, 200, 50, valSet)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []SignerSeriesPoint{
+		{Time: 100, Address: a, Proposals: 2, Seals: 3},
+		{Time: 100, Address: b, Proposals: 2, Seals: 3},
+		{Time: 100, Address: c, MissedTurns: 1},
+		{Time: 150, Address: a, Proposals: 1, Seals: 1},
+		{Time: 150, Address: b, Seals: 1},
+	}, signers)
+}
+
+func TestInsertHeader(t *testing.T) {
+	blk, err := Open(filepath.Join(t.TempDir(), "test.sqlite"))
+	assert.NoError(t, err)
+	defer blk.db.Close()
+
+	a := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	b := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	// The counters of the report are not stored, only what the block itself tells
+	report := &redt.BlockReport{
+		Proposer: redt.Validator{Address: a},
+		Signers:  []redt.Validator{{Address: a}, {Address: b}},
+		Counters: []redt.ReportValidator{{ValidatorStats: redt.ValidatorStats{Address: a, Proposals: 7, Seals: 9}}},
+	}
+	assert.NoError(t, blk.Begin())
+	assert.NoError(t, blk.InsertHeader(&types.Header{Number: big.NewInt(1)}, report, 0))
+	assert.NoError(t, blk.Commit())
+
+	var proposerCount int64
+	assert.NoError(t, blk.db.QueryRow("SELECT ProposerCount FROM blockchain WHERE Number = 1").Scan(&proposerCount))
+	assert.Equal(t, int64(0), proposerCount)
+
+	var asProposer, asSigner int
+	assert.NoError(t, blk.db.QueryRow("SELECT AsProposer, AsSigner FROM signers WHERE Address = ?", a.String()).Scan(&asProposer, &asSigner))
+	assert.Equal(t, []int{0, 0}, []int{asProposer, asSigner})
+	assert.NoError(t, blk.db.QueryRow("SELECT AsProposer, AsSigner FROM signers WHERE Address = ?", b.String()).Scan(&asProposer, &asSigner))
+	assert.Equal(t, []int{0, 0}, []int{asProposer, asSigner})
+}
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sqlite")
+	blk, err := Open(path)
+	assert.NoError(t, err)
+
+	// A database of a release which stored counters in the columns not used
+	_, err = blk.db.Exec("INSERT INTO blockchain (Number, Proposer, ProposerCount) VALUES (1, 'a', 7)")
+	assert.NoError(t, err)
+	_, err = blk.db.Exec("INSERT INTO signers VALUES (1, 'a', 7, 9), (2, 'b', 1, 1)")
+	assert.NoError(t, err)
+	_, err = blk.db.Exec("PRAGMA user_version = 0")
+	assert.NoError(t, err)
+	blk.Close()
+
+	blk, err = Open(path)
+	assert.NoError(t, err)
+	defer blk.Close()
+
+	var version, nonzero int
+	assert.NoError(t, blk.db.QueryRow("PRAGMA user_version").Scan(&version))
+	assert.Equal(t, schemaVersion, version)
+	assert.NoError(t, blk.db.QueryRow("SELECT COUNT(*) FROM blockchain WHERE ProposerCount != 0").Scan(&nonzero))
+	assert.Zero(t, nonzero)
+	assert.NoError(t, blk.db.QueryRow("SELECT COUNT(*) FROM signers WHERE AsProposer != 0 OR AsSigner != 0").Scan(&nonzero))
+	assert.Zero(t, nonzero)
+}
+
+func TestStoreBlock(t *testing.T) {
+	blk, err := Open(filepath.Join(t.TempDir(), "test.sqlite"))
+	assert.NoError(t, err)
+	defer blk.db.Close()
+
+	a := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	for number := int64(5); number <= 6; number++ {
+		report := &redt.BlockReport{
+			Number:   number,
+			Proposer: redt.Validator{Address: a},
+			Signers:  []redt.Validator{{Address: a}},
+		}
+		assert.NoError(t, blk.StoreBlock(&types.Header{Number: big.NewInt(number), Time: uint64(1000 + number)}, report))
+
+		// Storing a block twice does nothing
+		assert.NoError(t, blk.StoreBlock(&types.Header{Number: big.NewInt(number)}, report))
+	}
+
+	// The blocks are recorded in the ranges stored, and counted in the statistics of the period
+	ranges, err := blk.StoredRanges()
+	assert.NoError(t, err)
+	assert.Equal(t, []BlockRange{{Start: 5, End: 6}}, ranges)
+
+	numBlocks, stats, err := blk.StatsForPeriod(1000, 1010)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), numBlocks)
+	assert.Len(t, stats, 1)
+	assert.Equal(t, int64(2), stats[0].Seals)
+}
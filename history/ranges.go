@@ -0,0 +1,295 @@
+package history
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/hesusruiz/signers/redt"
+	"github.com/labstack/gommon/log"
+)
+
+// BlockRange is a contiguous range of block numbers, both ends included
+type BlockRange struct {
+	Start int64
+	End   int64
+}
+
+func (r BlockRange) String() string {
+	return fmt.Sprintf("%v-%v", r.Start, r.End)
+}
+
+// Len returns the number of blocks in the range
+func (r BlockRange) Len() int64 {
+	return r.End - r.Start + 1
+}
+
+// dbtx is implemented by the database and by its transactions
+type dbtx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// StoredRanges returns the ranges of blocks already in the database, in ascending order.
+// If the database was created before the ranges table existed, the ranges are rebuilt
+// from the blocks stored and saved for next time.
+func (b *Blockchain) StoredRanges() ([]BlockRange, error) {
+
+	ranges, err := b.inTransaction(storedRanges)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return ranges, nil
+}
+
+// AddRange records that a new range of blocks has been stored, merging it with the existing ones.
+// If a transaction is in progress the range is written in it, so it is recorded if and only if
+// the blocks inserted in the same transaction are.
+func (b *Blockchain) AddRange(r BlockRange) error {
+
+	_, err := b.inTransaction(func(tx dbtx) ([]BlockRange, error) {
+		ranges, err := storedRanges(tx)
+		if err != nil {
+			return nil, err
+		}
+		ranges = mergeRange(ranges, r)
+		return ranges, saveRanges(tx, ranges)
+	})
+	if err != nil {
+		log.Error(err)
+	}
+
+	return err
+}
+
+// inTransaction calls fn in the current transaction, or in a new one which is committed if fn succeeds
+func (b *Blockchain) inTransaction(fn func(tx dbtx) ([]BlockRange, error)) ([]BlockRange, error) {
+
+	if b.tx != nil {
+		return fn(b.tx)
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	ranges, err := fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return ranges, tx.Commit()
+}
+
+// storedRanges reads the ranges table, rebuilding it from the blocks stored if it is empty
+func storedRanges(tx dbtx) ([]BlockRange, error) {
+
+	ranges, err := queryRanges(tx, "SELECT StartNumber, EndNumber FROM ranges ORDER BY StartNumber")
+	if err != nil || len(ranges) > 0 {
+		return ranges, err
+	}
+
+	// The table is empty, check if there are blocks already stored
+	ranges, err = queryRanges(tx, rangesFromBlockchainStmt)
+	if err != nil || len(ranges) == 0 {
+		return ranges, err
+	}
+
+	return ranges, saveRanges(tx, ranges)
+}
+
+func queryRanges(tx dbtx, query string) ([]BlockRange, error) {
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ranges := make([]BlockRange, 0)
+	for rows.Next() {
+		var r BlockRange
+		err = rows.Scan(&r.Start, &r.End)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+
+	return ranges, rows.Err()
+}
+
+// saveRanges replaces the contents of the ranges table
+func saveRanges(tx dbtx, ranges []BlockRange) error {
+
+	_, err := tx.Exec("DELETE FROM ranges")
+	if err != nil {
+		return err
+	}
+
+	for _, r := range ranges {
+		_, err = tx.Exec(rangesTableInsertRecordStmt, r.Start, r.End)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeRange adds r to the sorted list of ranges, joining the ones that overlap or are adjacent
+func mergeRange(ranges []BlockRange, r BlockRange) []BlockRange {
+
+	all := make([]BlockRange, 0, len(ranges)+1)
+	all = append(all, ranges...)
+	all = append(all, r)
+	sort.Slice(all, func(i, j int) bool { return all[i].Start < all[j].Start })
+
+	merged := make([]BlockRange, 0, len(all))
+	for _, item := range all {
+		last := len(merged) - 1
+		if last >= 0 && item.Start <= merged[last].End+1 {
+			if item.End > merged[last].End {
+				merged[last].End = item.End
+			}
+			continue
+		}
+		merged = append(merged, item)
+	}
+
+	return merged
+}
+
+// missingRanges returns the parts of [from, to] not covered by the sorted list of stored ranges
+func missingRanges(stored []BlockRange, from int64, to int64) []BlockRange {
+
+	missing := make([]BlockRange, 0)
+	next := from
+
+	for _, r := range stored {
+		if r.End < next {
+			continue
+		}
+		if r.Start > to {
+			break
+		}
+		if r.Start > next {
+			missing = append(missing, BlockRange{Start: next, End: r.Start - 1})
+		}
+		next = r.End + 1
+	}
+
+	if next <= to {
+		missing = append(missing, BlockRange{Start: next, End: to})
+	}
+
+	return missing
+}
+
+// downloadRanges retrieves from the network and stores all the blocks in the given ranges.
+// The gap metadata is updated in the same transaction as the blocks, so an interrupted download
+// does not lose track of the blocks already stored.
+func downloadRanges(rt *redt.RedTNode, blk *Blockchain, gaps []BlockRange, descending bool) error {
+
+	for _, gap := range gaps {
+
+		fmt.Println("Downloading blocks", gap)
+
+		if err := downloadRange(rt, blk, gap, descending); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+}
+
+// downloadRange stores the blocks of a range, committing every few blocks.
+// If it fails, the blocks of the current transaction are discarded.
+func downloadRange(rt *redt.RedTNode, blk *Blockchain, gap BlockRange, descending bool) (err error) {
+
+	// We perform a Commit every 100 insertions
+	const insertsPerCommit = 100
+
+	// The blocks inserted in the current transaction
+	var pending BlockRange
+	count := 0
+
+	// Start a db transaction
+	err = blk.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			blk.Rollback()
+		}
+	}()
+
+	for n := int64(0); n < gap.Len(); n++ {
+
+		// Calculate the block number depending on the direction
+		i := gap.Start + n
+		if descending {
+			i = gap.End - n
+		}
+
+		// Get the block data
+		header, report, err := rt.ReportForBlockNumber(i)
+		if err != nil {
+			return err
+		}
+
+		// Insert
+		err = blk.InsertHeader(header, report, 0)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		// Extend the range of pending blocks
+		if count == 0 {
+			pending = BlockRange{Start: i, End: i}
+		} else if descending {
+			pending.Start = i
+		} else {
+			pending.End = i
+		}
+
+		count++
+
+		// Commit if enough insertions have been made
+		if count >= insertsPerCommit {
+			fmt.Println("Block ", i)
+
+			// Record the range, commit and start a new transaction
+			err = blk.AddRange(pending)
+			if err != nil {
+				return err
+			}
+			err = blk.Commit()
+			if err != nil {
+				return err
+			}
+			err = blk.Begin()
+			if err != nil {
+				return err
+			}
+			count = 0
+		}
+
+	}
+
+	// Commit the last set of inserts
+	if count > 0 {
+		err = blk.AddRange(pending)
+		if err != nil {
+			return err
+		}
+	}
+	return blk.Commit()
+}
@@ -0,0 +1,183 @@
+package history
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/labstack/gommon/log"
+)
+
+// The queries aggregate the blocks in buckets of a fixed number of seconds, so the size of the
+// result depends on the number of buckets and not on the number of blocks in the period.
+
+// The interval is only calculated between consecutive blocks, so gaps in the stored ranges
+// do not appear as long intervals
+var blockSeriesStmt = `
+SELECT (Time / ?) * ? AS Bucket, COUNT(*),
+  COALESCE(AVG(Interval), 0), COALESCE(MAX(Interval), 0), SUM(GasUsed), SUM(GasLimit)
+FROM (
+  SELECT Time, GasUsed, GasLimit,
+    CASE WHEN Number - LAG(Number) OVER w = 1 THEN Time - LAG(Time) OVER w END AS Interval
+  FROM blockchain WHERE Time >= ? AND Time < ?
+  WINDOW w AS (ORDER BY Number)
+) GROUP BY Bucket ORDER BY Bucket`
+
+var proposalSeriesStmt = `
+SELECT (Time / ?) * ? AS Bucket, Proposer, COUNT(*)
+FROM blockchain WHERE Time >= ? AND Time < ?
+GROUP BY Bucket, Proposer`
+
+var sealSeriesStmt = `
+SELECT (b.Time / ?) * ? AS Bucket, s.Address, COUNT(*)
+FROM signers s JOIN blockchain b ON s.Number = b.Number WHERE b.Time >= ? AND b.Time < ?
+GROUP BY Bucket, s.Address`
+
+// missedTurnSeriesStmt counts the blocks where the validator following the proposer of the previous
+// block, by the round-robin algorithm, did not propose. The history does not record the validator set,
+// so it is passed as (address, index) values. We only know the expected proposer if we have the previous block.
+func missedTurnSeriesStmt(size int) string {
+	values := strings.TrimSuffix(strings.Repeat("(?, ?),", size), ",")
+	return `
+WITH vals(Address, Idx) AS (VALUES ` + values + `),
+blocks AS (
+  SELECT Number, Time, LOWER(Proposer) AS Proposer,
+    LAG(Number) OVER w AS PrevNumber, LOWER(LAG(Proposer) OVER w) AS PrevProposer
+  FROM blockchain WHERE Time >= ? AND Time < ?
+  WINDOW w AS (ORDER BY Number)
+)
+SELECT (b.Time / ?) * ? AS Bucket, e.Address, COUNT(*)
+FROM blocks b
+JOIN vals p ON LOWER(p.Address) = b.PrevProposer
+JOIN vals e ON e.Idx = (p.Idx + 1) % ?
+WHERE b.Number = b.PrevNumber + 1 AND b.Proposer != LOWER(e.Address)
+GROUP BY Bucket, e.Address`
+}
+
+// BlockSeriesPoint is the aggregated data of the blocks in a bucket of time
+type BlockSeriesPoint struct {
+	Time        int64   `json:"time"` // Start of the bucket, in Unix seconds
+	Blocks      int64   `json:"blocks"`
+	AvgInterval float64 `json:"avgInterval"`
+	MaxInterval int64   `json:"maxInterval"`
+	GasUsed     int64   `json:"gasUsed"`
+	GasLimit    int64   `json:"gasLimit"`
+}
+
+// SignerSeriesPoint is the activity of a signer in a bucket of time
+type SignerSeriesPoint struct {
+	Time        int64  `json:"time"`
+	Address     string `json:"address"`
+	Proposals   int64  `json:"proposals"`
+	Seals       int64  `json:"seals"`
+	MissedTurns int64  `json:"missedTurns"`
+}
+
+// BlockSeries aggregates the blocks stored with a timestamp in [from, to), in buckets of the given seconds
+func (b *Blockchain) BlockSeries(from int64, to int64, bucket int64) ([]BlockSeriesPoint, error) {
+
+	rows, err := b.db.Query(blockSeriesStmt, bucket, bucket, from, to)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []BlockSeriesPoint{}
+	for rows.Next() {
+		var p BlockSeriesPoint
+		err = rows.Scan(&p.Time, &p.Blocks, &p.AvgInterval, &p.MaxInterval, &p.GasUsed, &p.GasLimit)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
+
+// SignerSeries calculates the proposals, seals and missed turns of each signer in the blocks stored with
+// a timestamp in [from, to), in buckets of the given seconds. Buckets without activity of a signer are not
+// included. The missed turns are calculated with the given validator set, as the history does not record it.
+func (b *Blockchain) SignerSeries(from int64, to int64, bucket int64, valSet []common.Address) ([]SignerSeriesPoint, error) {
+
+	type key struct {
+		time    int64
+		address string
+	}
+	byKey := map[key]*SignerSeriesPoint{}
+	var order []key
+
+	getPoint := func(time int64, address string) *SignerSeriesPoint {
+		k := key{time, address}
+		p := byKey[k]
+		if p == nil {
+			p = &SignerSeriesPoint{Time: time, Address: address}
+			byKey[k] = p
+			order = append(order, k)
+		}
+		return p
+	}
+
+	for _, q := range []struct {
+		stmt string
+		set  func(p *SignerSeriesPoint, count int64)
+	}{
+		{proposalSeriesStmt, func(p *SignerSeriesPoint, count int64) { p.Proposals = count }},
+		{sealSeriesStmt, func(p *SignerSeriesPoint, count int64) { p.Seals = count }},
+	} {
+		err := b.queryBucketCounts(func(time int64, address string, count int64) {
+			q.set(getPoint(time, address), count)
+		}, q.stmt, bucket, bucket, from, to)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+	}
+
+	// The missed turns, in the validators of the set
+	if len(valSet) > 0 {
+		args := make([]any, 0, 2*len(valSet)+5)
+		for i, val := range valSet {
+			args = append(args, val.Hex(), i)
+		}
+		args = append(args, from, to, bucket, bucket, len(valSet))
+
+		err := b.queryBucketCounts(func(time int64, address string, count int64) {
+			getPoint(time, address).MissedTurns = count
+		}, missedTurnSeriesStmt(len(valSet)), args...)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+	}
+
+	points := make([]SignerSeriesPoint, len(order))
+	for i, k := range order {
+		points[i] = *byKey[k]
+	}
+
+	return points, nil
+}
+
+// queryBucketCounts runs a query returning rows of (bucket, address, count) and calls fn for each row
+func (b *Blockchain) queryBucketCounts(fn func(time int64, address string, count int64), query string, args ...any) error {
+
+	rows, err := b.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var time int64
+		var address string
+		var count int64
+		if err = rows.Scan(&time, &address, &count); err != nil {
+			return err
+		}
+		fn(time, address, count)
+	}
+
+	return rows.Err()
+}
@@ -1,15 +1,19 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"time"
 
 	"github.com/labstack/gommon/log"
 
+	"github.com/hesusruiz/signers/alerts"
 	"github.com/hesusruiz/signers/history"
 	"github.com/hesusruiz/signers/logfilter"
+	"github.com/hesusruiz/signers/metrics"
 	"github.com/hesusruiz/signers/redt"
 	"github.com/hesusruiz/signers/serve"
+	"github.com/hesusruiz/signers/tui"
 	"github.com/urfave/cli/v2"
 )
 
@@ -17,6 +21,47 @@ import (
 var localNodeHTTP = "http://127.0.0.1:22000"
 var localNodeWS = "ws://127.0.0.1:22001"
 
+// The flags of the statistics, alerts and stall detector, common to the monitor commands and serve
+var statsFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "rules",
+		Usage:   "file with the alerting rules and notifiers (optional)",
+		Aliases: []string{"a"},
+	},
+	&cli.Int64Flag{
+		Name:  "period",
+		Value: 5,
+		Usage: "expected seconds between blocks",
+	},
+	&cli.Int64Flag{
+		Name:  "stall",
+		Value: 30,
+		Usage: "seconds without a block beyond the expected period before the chain is reported as stalled",
+	},
+	&cli.StringFlag{
+		Name:  "state",
+		Usage: "file where the counters are saved periodically, to continue from them after a restart (optional)",
+	},
+	&cli.StringFlag{
+		Name:  "windows",
+		Usage: "rolling windows of recent blocks shown next to the counters since the start, as numbers of blocks or durations",
+		Value: "100,1h",
+	},
+}
+
+// The flags of the monitor commands: the ones of the statistics and how the blocks are presented
+var monitorFlags = append(append([]cli.Flag{}, statsFlags...),
+	&cli.BoolFlag{
+		Name:  "plain",
+		Usage: "print a box for each block instead of the full-screen interface, which is used when the output is a terminal",
+	},
+	&cli.StringFlag{
+		Name:    "output",
+		Usage:   "write a record per block for other programs, in format json, jsonl or csv",
+		Aliases: []string{"o"},
+	},
+)
+
 func main() {
 
 	// Define commands, parse command line arguments and start execution
@@ -24,8 +69,8 @@ func main() {
 		Usage: "Monitoring of block signers activity for the Alastria RedT blockchain network",
 		UsageText: `signers [global options] command [command options]
 			where 'nodeURL' is the address of the Quorum node.
-			It supports both HTTP and WebSockets endpoints.
-			By default it uses WebSockets and for HTTP you have to use the 'poll' subcommand.`,
+			It supports WebSockets, HTTP and IPC endpoints.
+			The 'watch' subcommand selects the transport from the url.`,
 
 		EnableBashCompletion:   true,
 		UseShortOptionHandling: true,
@@ -44,12 +89,48 @@ func main() {
 		},
 	}
 
+	watchCMD := &cli.Command{
+		Name:      "watch",
+		Usage:     "monitor the signers activity, selecting the transport from the url",
+		UsageText: "signers watch [options]",
+
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:     "url",
+				Value:    localNodeWS,
+				Usage:    "url of the endpoint of blockchain node: ws(s), http(s) or the path of the IPC socket",
+				Aliases:  []string{"u"},
+				Required: false,
+			},
+			&cli.Int64Flag{
+				Name:    "blocks",
+				Value:   10,
+				Usage:   "number of blocks in the past to process before starting",
+				Aliases: []string{"b"},
+			},
+			&cli.Int64Flag{
+				Name:    "refresh",
+				Value:   2,
+				Usage:   "polling interval in seconds, when the node does not support subscriptions",
+				Aliases: []string{"r"},
+			},
+		}, monitorFlags...),
+
+		Action: func(c *cli.Context) error {
+			m, err := monitorOptions(c)
+			if err != nil {
+				return err
+			}
+			return redt.Watch(c.String("url"), c.Int64("blocks"), c.Int64("refresh"), c.String("state"), m.windows, m.display, m.observers...)
+		},
+	}
+
 	monitorWSCMD := &cli.Command{
 		Name:      "monitor",
 		Usage:     "monitor the signers activity via WebSockets events",
 		UsageText: "signers monitor [options]",
 
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:     "url",
 				Value:    localNodeWS,
@@ -63,13 +144,14 @@ func main() {
 				Usage:   "number of blocks in the past to process",
 				Aliases: []string{"b"},
 			},
-		},
+		}, monitorFlags...),
 
 		Action: func(c *cli.Context) error {
-			url := c.String("url")
-			numBlocks := c.Int64("blocks")
-			redt.MonitorSignersWS(url, numBlocks)
-			return nil
+			m, err := monitorOptions(c)
+			if err != nil {
+				return err
+			}
+			return redt.MonitorSignersWS(c.String("url"), c.Int64("blocks"), c.String("state"), m.windows, m.display, m.observers...)
 		},
 	}
 
@@ -77,7 +159,7 @@ func main() {
 		Name:      "poll",
 		Usage:     "monitor the signers activity via HTTP polling",
 		UsageText: "signers poll [options]",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:     "url",
 				Value:    localNodeHTTP,
@@ -97,14 +179,14 @@ func main() {
 				Usage:   "refresh interval for presentation. All blocks are processed independent of this value",
 				Aliases: []string{"r"},
 			},
-		},
+		}, monitorFlags...),
 
 		Action: func(c *cli.Context) error {
-			url := c.String("url")
-			numBlocks := c.Int64("blocks")
-			refresh := c.Int64("refresh")
-			redt.MonitorSigners(url, numBlocks, refresh)
-			return nil
+			m, err := monitorOptions(c)
+			if err != nil {
+				return err
+			}
+			return redt.MonitorSigners(c.String("url"), c.Int64("blocks"), c.Int64("refresh"), c.String("state"), m.windows, m.display, m.observers...)
 		},
 	}
 
@@ -143,11 +225,11 @@ func main() {
 		Name:      "serve",
 		Usage:     "run a web server to display signers behaviour in real time",
 		UsageText: "signers serve [options]",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:     "url",
 				Value:    localNodeWS,
-				Usage:    "url of the endpoint of blockchain node",
+				Usage:    "url of the endpoint of blockchain node: ws(s) for subscriptions, http(s) or an IPC path for polling",
 				Aliases:  []string{"u"},
 				Required: false,
 			},
@@ -163,21 +245,158 @@ func main() {
 				Usage:   "port of the IP address for the web server",
 				Aliases: []string{"p"},
 			},
+			&cli.Int64Flag{
+				Name:    "blocks",
+				Value:   10,
+				Usage:   "number of blocks in the past to process before starting",
+				Aliases: []string{"b"},
+			},
+			&cli.Int64Flag{
+				Name:    "refresh",
+				Value:   2,
+				Usage:   "polling interval in seconds, when the url is HTTP or IPC",
+				Aliases: []string{"r"},
+			},
+			&cli.StringFlag{
+				Name:    "dsn",
+				Usage:   "dsn of the SQLite database with history data, used by the API and the daily digest, where each block processed is stored (optional)",
+				Aliases: []string{"d"},
+			},
+			&cli.Int64Flag{
+				Name:  "maxage",
+				Value: 60,
+				Usage: "maximum seconds without receiving a block before the server is reported as not ready",
+			},
+			&cli.StringFlag{
+				Name:  "auth",
+				Usage: "YAML file with the tokens, users and OIDC provider allowed to access the web server (optional)",
+			},
+			&cli.StringFlag{
+				Name:  "templates",
+				Usage: "directory with templates and static files replacing the embedded ones (optional)",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "YAML file with the networks to monitor, instead of the flags of a single network (optional)",
+				Aliases: []string{"c"},
+			},
+		}, statsFlags...),
+
+		Action: func(c *cli.Context) error {
+			var cfg *serve.Config
+			var err error
+
+			if len(c.String("config")) > 0 {
+				cfg, err = serve.LoadConfig(c.String("config"))
+				if err != nil {
+					return err
+				}
+			} else {
+				cfg = &serve.Config{
+					IP:          c.String("ip"),
+					Port:        c.Int64("port"),
+					MaxBlockAge: c.Int64("maxage"),
+					Networks: []serve.NetworkConfig{{
+						Name:    "redt",
+						URLs:    []string{c.String("url")},
+						Blocks:  c.Int64("blocks"),
+						Refresh: c.Int64("refresh"),
+						DSN:     c.String("dsn"),
+						Rules:   c.String("rules"),
+						Windows: c.String("windows"),
+						State:   c.String("state"),
+
+						BlockPeriod:  c.Int64("period"),
+						StallTimeout: c.Int64("stall"),
+					}},
+				}
+				err = cfg.Validate()
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(c.String("templates")) > 0 {
+				cfg.Templates = c.String("templates")
+			}
+
+			// The authentication file replaces the one in the configuration
+			if len(c.String("auth")) > 0 {
+				cfg.Auth, err = serve.LoadAuthConfig(c.String("auth"))
+				if err != nil {
+					return err
+				}
+			}
+
+			serve.ServeSigners(cfg)
+			return nil
+		},
+	}
+
+	exporterCMD := &cli.Command{
+		Name:      "exporter",
+		Usage:     "expose the signers activity as Prometheus metrics, without web UI",
+		UsageText: "signers exporter [options]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "url",
+				Value:    localNodeWS,
+				Usage:    "url of the endpoint of blockchain node: ws(s) for subscriptions, http(s) or an IPC path for polling",
+				Aliases:  []string{"u"},
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:    "ip",
+				Value:   "0.0.0.0",
+				Usage:   "IP address of the metrics server",
+				Aliases: []string{"i"},
+			},
+			&cli.Int64Flag{
+				Name:    "port",
+				Value:   9500,
+				Usage:   "port of the IP address for the metrics server",
+				Aliases: []string{"p"},
+			},
 		},
 
 		Action: func(c *cli.Context) error {
 			url := c.String("url")
 			ip := c.String("ip")
 			port := c.Int64("port")
-			serve.ServeSigners(url, ip, port)
+			metrics.RunExporter(url, ip, port)
 			return nil
 		},
 	}
 
+	testAlertCMD := &cli.Command{
+		Name:      "testalert",
+		Usage:     "send a test alert to all the notifiers in the rules file",
+		UsageText: "signers testalert --rules file",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "rules",
+				Usage:    "file with the alerting rules and notifiers",
+				Aliases:  []string{"a"},
+				Required: true,
+			},
+		},
+
+		Action: func(c *cli.Context) error {
+			engine, err := alerts.NewEngineFromFile(c.String("rules"))
+			if err != nil {
+				return err
+			}
+			return engine.SendTest()
+		},
+	}
+
 	historyCMD := &cli.Command{
 		Name:      "history",
 		Usage:     "download blockchain headers into SQLite database, from current towards genesis",
-		UsageText: "signers poll [options]",
+		UsageText: "signers history [options]",
+		Description: "Downloads the blocks not yet stored, from the highest one stored (or the current one if the database\n" +
+			"is empty) towards genesis, filling all the gaps between the ranges stored. With --from, and optionally\n" +
+			"--to, downloads only the blocks of that range.",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:     "url",
@@ -200,13 +419,38 @@ func main() {
 				Aliases:  []string{"s"},
 				Required: false,
 			},
+			&cli.Int64Flag{
+				Name:  "from",
+				Value: -1,
+				Usage: "download only the blocks starting at this number, merging with the ones stored",
+			},
+			&cli.Int64Flag{
+				Name:  "to",
+				Value: -1,
+				Usage: "last block to download when 'from' is specified (default: current block)",
+			},
 		},
 
 		Action: func(c *cli.Context) error {
 			url := c.String("url")
 			dsn := c.String("dsn")
 			stats := c.Bool("stats")
-			err := history.HistoryBackwards(url, dsn, stats)
+			from := c.Int64("from")
+			to := c.Int64("to")
+
+			if to >= 0 && from < 0 {
+				return errors.New("the option 'to' requires 'from'")
+			}
+			if from >= 0 && stats {
+				return errors.New("the options 'from' and 'stats' can not be used together")
+			}
+
+			var err error
+			if from >= 0 {
+				err = history.HistoryRange(url, dsn, from, to)
+			} else {
+				err = history.HistoryBackwards(url, dsn, stats)
+			}
 			if err != nil {
 				log.Error(err)
 			}
@@ -255,11 +499,14 @@ func main() {
 	}
 
 	app.Commands = []*cli.Command{
+		watchCMD,
 		monitorWSCMD,
 		monitorCMD,
 		displayPeersCMD,
 		logfilterCMD,
 		serveCMD,
+		exporterCMD,
+		testAlertCMD,
 		historyCMD,
 		historyForwardCMD,
 	}
@@ -272,3 +519,70 @@ func main() {
 	}
 
 }
+
+// alertsEngine creates the alerting engine if a rules file was specified
+func alertsEngine(rulesFile string) (*alerts.Engine, error) {
+	if len(rulesFile) == 0 {
+		return nil, nil
+	}
+	return alerts.NewEngineFromFile(rulesFile)
+}
+
+// display returns how the monitor commands present the blocks: the machine-readable output if a
+// format was specified, or the full-screen interface unless the plain output was requested or
+// the output is not a terminal
+func display(output string, plain bool) (redt.Display, error) {
+	if len(output) > 0 {
+		return redt.NewOutputDisplay(output, os.Stdout)
+	}
+	if plain || !tui.IsTerminal() {
+		return nil, nil
+	}
+	return tui.New(), nil
+}
+
+// monitor are the options of the monitor commands which are not passed as they are
+type monitor struct {
+	windows   []redt.Window
+	display   redt.Display
+	observers []redt.BlockObserver
+}
+
+// monitorOptions reads the options of the monitor commands from the monitorFlags
+func monitorOptions(c *cli.Context) (*monitor, error) {
+	var m monitor
+	var err error
+
+	m.observers, err = blockObservers(c.String("rules"), c.Int64("period"), c.Int64("stall"))
+	if err != nil {
+		return nil, err
+	}
+	m.windows, err = redt.ParseWindows(c.String("windows"))
+	if err != nil {
+		return nil, err
+	}
+	m.display, err = display(c.String("output"), c.Bool("plain"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// blockObservers returns the observers of the blocks processed configured in the command line
+func blockObservers(rulesFile string, period int64, stall int64) ([]redt.BlockObserver, error) {
+	var observers []redt.BlockObserver
+
+	engine, err := alertsEngine(rulesFile)
+	if err != nil {
+		return nil, err
+	}
+	if engine != nil {
+		observers = append(observers, engine)
+	}
+
+	// The stall detector notifies the other observers, including the alerts engine
+	observers = append(observers, redt.NewStallDetector(time.Duration(period)*time.Second, time.Duration(stall)*time.Second))
+
+	return observers, nil
+}
@@ -0,0 +1,221 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethertypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/hesusruiz/signers/redt"
+	qtypes "github.com/hesusruiz/signers/types"
+	"github.com/rs/zerolog/log"
+)
+
+// Buckets for the interval between blocks, in seconds, around the expected period (redt.DefaultBlockPeriod)
+var intervalBuckets = []float64{1, 2, 3, 4, 5, 7, 10, 15, 30, 60}
+
+// The interval to poll the node when the url is HTTP or IPC
+const exporterPollInterval = 2 * time.Second
+
+// Exporter collects the metrics of the blocks observed, and reads the per-validator
+// counters from the RedTNode when scraped
+type Exporter struct {
+	rt        *redt.RedTNode
+	connected func() bool
+
+	mu         sync.Mutex
+	headNumber int64
+	headTime   uint64
+	numSigners int
+	intervals  *Histogram
+}
+
+// NewExporter creates an exporter for the node. The connected function reports the state of the
+// connection to the node, and can be nil if unknown.
+func NewExporter(rt *redt.RedTNode, connected func() bool) *Exporter {
+	return &Exporter{
+		rt:        rt,
+		connected: connected,
+		intervals: NewHistogram(intervalBuckets...),
+	}
+}
+
+// ObserveBlock records a new head of the chain and the signers of its block
+func (e *Exporter) ObserveBlock(header *ethertypes.Header, signers []common.Address) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	number := header.Number.Int64()
+	if number <= e.headNumber {
+		return
+	}
+
+	// The interval is only meaningful with respect to the previous block
+	if e.headNumber > 0 && number == e.headNumber+1 {
+		e.intervals.Observe(float64(header.Time - e.headTime))
+	}
+
+	e.headNumber = number
+	e.headTime = header.Time
+	e.numSigners = len(signers)
+}
+
+// ServeHTTP implements http.Handler for the /metrics endpoint
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	mw := NewWriter(w)
+	e.Write(mw)
+	if err := mw.Flush(); err != nil {
+		log.Error().Err(err).Msg("writing metrics")
+	}
+}
+
+// Write outputs all the metrics
+func (e *Exporter) Write(mw *Writer) {
+
+	e.mu.Lock()
+	headNumber := e.headNumber
+	headTime := e.headTime
+	numSigners := e.numSigners
+	e.mu.Unlock()
+
+	mw.Header("signers_head_block_number", "gauge", "Number of the most recent block received")
+	mw.Sample("signers_head_block_number", float64(headNumber))
+
+	mw.Header("signers_seconds_since_last_block", "gauge", "Seconds elapsed since the timestamp of the most recent block")
+	if headTime > 0 {
+		mw.Sample("signers_seconds_since_last_block", float64(time.Now().Unix()-int64(headTime)))
+	}
+
+	mw.Header("signers_block_interval_seconds", "histogram", "Interval between consecutive blocks")
+	e.intervals.Write(mw, "signers_block_interval_seconds")
+
+	stats := e.rt.Stats()
+
+	mw.Header("signers_validator_set_size", "gauge", "Number of validators in the current validator set")
+	mw.Sample("signers_validator_set_size", float64(len(stats)))
+
+	mw.Header("signers_quorum_margin", "gauge", "Committed seals in the most recent block above the minimum required")
+	if headNumber > 0 {
+		mw.Sample("signers_quorum_margin", float64(numSigners-e.rt.QuorumSize()))
+	}
+
+	mw.Header("signers_upstream_connected", "gauge", "Whether the connection to the blockchain node is established")
+	if e.connected != nil {
+		mw.Sample("signers_upstream_connected", boolValue(e.connected()))
+	}
+
+	mw.Header("signers_validator_proposals_total", "counter", "Blocks proposed by the validator")
+	for _, st := range stats {
+		mw.Sample("signers_validator_proposals_total", float64(st.Proposals), validatorLabels(st)...)
+	}
+
+	mw.Header("signers_validator_seals_total", "counter", "Blocks with a committed seal of the validator")
+	for _, st := range stats {
+		mw.Sample("signers_validator_seals_total", float64(st.Seals), validatorLabels(st)...)
+	}
+
+	mw.Header("signers_validator_missed_seals_total", "counter", "Blocks without a committed seal of the validator")
+	for _, st := range stats {
+		mw.Sample("signers_validator_missed_seals_total", float64(st.MissedSeals), validatorLabels(st)...)
+	}
+
+	mw.Header("signers_validator_missed_turns_total", "counter", "Blocks that the validator should have proposed but were proposed by another one")
+	for _, st := range stats {
+		mw.Sample("signers_validator_missed_turns_total", float64(st.MissedTurns), validatorLabels(st)...)
+	}
+
+}
+
+func validatorLabels(st redt.ValidatorStats) []Label {
+	return []Label{
+		{"address", st.Address.Hex()},
+		{"operator", st.Operator},
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// warmUp observes the last block included in the statistics, so the metrics of the head are
+// available before the next block is received
+func (e *Exporter) warmUp() {
+
+	header, err := e.rt.HeaderByNumber(e.rt.LastBlockProcessed())
+	if err != nil {
+		log.Error().Err(err).Msg("warming up")
+		return
+	}
+
+	_, signers, err := redt.SignersFromBlock(header)
+	if err != nil {
+		log.Error().Err(err).Msg("warming up")
+		return
+	}
+
+	e.ObserveBlock(header, signers)
+}
+
+// processHeader updates the statistics and the metrics with the block
+func (e *Exporter) processHeader(header *ethertypes.Header) error {
+
+	_, signers, err := e.rt.UpdateStatisticsForBlock(header)
+	if err != nil {
+		return err
+	}
+
+	e.ObserveBlock(header, signers)
+	return nil
+}
+
+// RunExporter is a lightweight process that only exposes the metrics, without web UI.
+// It receives the new blocks via WebSockets, or by polling the node with HTTP and IPC urls.
+func RunExporter(url string, ip string, port int64) {
+
+	// Connect to the RedT node
+	rt, err := redt.NewRedTNode(url)
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+		os.Exit(1)
+	}
+
+	source, err := redt.NewHeadSource(url, rt, exporterPollInterval)
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	defer source.Stop()
+
+	exporter := NewExporter(rt, source.Connected)
+
+	// The counters start at the current block, which is also the first head of the metrics
+	if err := rt.StartStats(0, ""); err != nil {
+		// The statistics start with the first block received
+		log.Error().Err(err).Msg("starting the statistics")
+	} else {
+		exporter.warmUp()
+	}
+
+	inputCh := make(chan qtypes.RawHeader)
+	err = source.SubscribeChainHead(inputCh)
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+
+	// Process the blocks in the background
+	go rt.ProcessNewBlocks(inputCh, exporter.processHeader)
+
+	serverIP := fmt.Sprintf("%v:%v", ip, port)
+	log.Info().Msgf("Serving metrics on http://%v/metrics", serverIP)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	err = http.ListenAndServe(serverIP, mux)
+	log.Fatal().Err(err).Msg("")
+}
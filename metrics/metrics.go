@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Label is a name/value pair attached to a sample
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Writer formats metrics in the Prometheus text exposition format, version 0.0.4
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Header writes the HELP and TYPE lines of a metric family. It must be called once, before its samples.
+func (mw *Writer) Header(name string, typ string, help string) {
+	mw.printf("# HELP %s %s\n", name, escape(help, false))
+	mw.printf("# TYPE %s %s\n", name, typ)
+}
+
+// Sample writes a single sample of a metric
+func (mw *Writer) Sample(name string, value float64, labels ...Label) {
+	mw.printf("%s%s %s\n", name, formatLabels(labels), formatValue(value))
+}
+
+// Flush writes any buffered data and returns the first error found while writing
+func (mw *Writer) Flush() error {
+	if mw.err != nil {
+		return mw.err
+	}
+	return mw.w.Flush()
+}
+
+func (mw *Writer) printf(format string, args ...any) {
+	if mw.err != nil {
+		return
+	}
+	_, mw.err = fmt.Fprintf(mw.w, format, args...)
+}
+
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = fmt.Sprintf("%s=\"%s\"", l.Name, escape(l.Value, true))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// escape backslashes and line feeds, and also double quotes in label values
+func escape(s string, quotes bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quotes {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
+
+// Histogram counts observations in cumulative buckets, as defined by Prometheus
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// NewHistogram creates a histogram with the given upper bounds. The +Inf bucket is implicit.
+func NewHistogram(buckets ...float64) *Histogram {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &Histogram{
+		buckets: b,
+		counts:  make([]uint64, len(b)),
+	}
+}
+
+// Observe adds a value to the histogram
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// Write outputs the bucket, sum and count samples of the histogram
+func (h *Histogram) Write(mw *Writer, name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upper := range h.buckets {
+		mw.Sample(name+"_bucket", float64(h.counts[i]), Label{"le", formatValue(upper)})
+	}
+	mw.Sample(name+"_bucket", float64(h.count), Label{"le", "+Inf"})
+	mw.Sample(name+"_sum", h.sum)
+	mw.Sample(name+"_count", float64(h.count))
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	mw := NewWriter(&buf)
+
+	mw.Header("signers_validator_seals_total", "counter", "Blocks sealed")
+	mw.Sample("signers_validator_seals_total", 42, Label{"operator", `My "Node"`})
+	assert.NoError(t, mw.Flush())
+
+	expected := `# HELP signers_validator_seals_total Blocks sealed
+# TYPE signers_validator_seals_total counter
+signers_validator_seals_total{operator="My \"Node\""} 42
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram(5, 1)
+	h.Observe(0.5)
+	h.Observe(3)
+	h.Observe(10)
+
+	var buf bytes.Buffer
+	mw := NewWriter(&buf)
+	h.Write(mw, "interval")
+	assert.NoError(t, mw.Flush())
+
+	expected := `interval_bucket{le="1"} 1
+interval_bucket{le="5"} 2
+interval_bucket{le="+Inf"} 3
+interval_sum 13.5
+interval_count 3
+`
+	assert.Equal(t, expected, buf.String())
+}
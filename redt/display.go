@@ -0,0 +1,51 @@
+package redt
+
+import (
+	"fmt"
+
+	qtypes "github.com/hesusruiz/signers/types"
+	"github.com/rs/zerolog/log"
+)
+
+// Display presents the blocks processed by the monitor commands, instead of printing a box per block
+type Display interface {
+	BlockObserver
+
+	// Run shows the display until the user quits. The blocks are processed in the background.
+	Run() error
+}
+
+// SourceReporter is implemented by the displays which show how the new blocks are received
+type SourceReporter interface {
+	SetSource(description string)
+}
+
+// runDisplay processes the blocks notified by the source in the background, while the display runs
+func (rt *RedTNode) runDisplay(source HeadSource, display Display) error {
+
+	inputCh := make(chan qtypes.RawHeader)
+	err := source.SubscribeChainHead(inputCh)
+	if err != nil {
+		return err
+	}
+	defer source.Stop()
+
+	// Tell how the blocks are received, for the sources which can describe it
+	logSource(source)
+	if description, ok := source.(fmt.Stringer); ok {
+		if r, ok := display.(SourceReporter); ok {
+			r.SetSource(description.String())
+		}
+	}
+
+	go rt.ProcessNewBlocks(inputCh, rt.includeHeader)
+
+	return display.Run()
+}
+
+// logSource tells how the blocks are received, for the sources which can describe it
+func logSource(source HeadSource) {
+	if description, ok := source.(fmt.Stringer); ok {
+		log.Info().Msgf("receiving the new blocks via %v", description)
+	}
+}
@@ -0,0 +1,29 @@
+package redt
+
+// BlockObserver is notified of the monitoring events of a RedTNode
+type BlockObserver interface {
+	// Start is called once, when the observer is added to the node
+	Start(rt *RedTNode)
+
+	// BlockProcessed is called with the report of each new block, in order and from a single goroutine at a time
+	BlockProcessed(r *BlockReport)
+}
+
+// AddObserver registers an observer to be notified of the new blocks processed
+func (rt *RedTNode) AddObserver(o BlockObserver) {
+	rt.observersLock.Lock()
+	rt.observers = append(rt.observers, o)
+	rt.observersLock.Unlock()
+
+	o.Start(rt)
+}
+
+// notifyObservers sends the report to all observers. It must be called without holding the counters lock.
+func (rt *RedTNode) notifyObservers(r *BlockReport) {
+	rt.observersLock.Lock()
+	defer rt.observersLock.Unlock()
+
+	for _, o := range rt.observers {
+		o.BlockProcessed(r)
+	}
+}
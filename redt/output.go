@@ -0,0 +1,134 @@
+package redt
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The formats of the machine-readable output of the monitor commands
+const (
+	OutputJSON  = "json"  // An indented JSON object per block
+	OutputJSONL = "jsonl" // A JSON object per line
+	OutputCSV   = "csv"   // A header and a line per block
+)
+
+// The columns of the CSV output. Lists of validators are separated by ';'.
+var csvHeader = []string{
+	"number", "hash", "timestamp", "time", "interval",
+	"proposer", "proposer_operator", "expected_proposer", "expected_proposer_operator", "missed_turn",
+	"signers", "signers_operators", "missing", "missing_operators",
+	"gas_limit", "gas_used",
+}
+
+// OutputDisplay writes a record per block in a machine-readable format, without any decoration.
+// It implements Display.
+type OutputDisplay struct {
+	format string
+	w      io.Writer
+	csv    *csv.Writer
+	rt     *RedTNode
+	errCh  chan error
+}
+
+// NewOutputDisplay creates the display for one of the output formats
+func NewOutputDisplay(format string, w io.Writer) (*OutputDisplay, error) {
+
+	o := &OutputDisplay{
+		format: format,
+		w:      w,
+		errCh:  make(chan error, 1),
+	}
+
+	switch format {
+	case OutputJSON, OutputJSONL:
+	case OutputCSV:
+		o.csv = csv.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown output format '%v', it must be %v, %v or %v", format, OutputJSON, OutputJSONL, OutputCSV)
+	}
+
+	return o, nil
+}
+
+// Start writes the header of the CSV output
+func (o *OutputDisplay) Start(rt *RedTNode) {
+	o.rt = rt
+
+	if o.csv != nil {
+		o.csv.Write(csvHeader)
+		o.csv.Flush()
+		o.fail(o.csv.Error())
+	}
+}
+
+// BlockProcessed writes the record of the block
+func (o *OutputDisplay) BlockProcessed(r *BlockReport) {
+	o.fail(o.write(r))
+}
+
+// Run waits until the output can not be written, for example because the reader exited
+func (o *OutputDisplay) Run() error {
+	return <-o.errCh
+}
+
+func (o *OutputDisplay) fail(err error) {
+	if err == nil {
+		return
+	}
+	select {
+	case o.errCh <- err:
+	default:
+	}
+}
+
+func (o *OutputDisplay) write(r *BlockReport) error {
+
+	switch o.format {
+
+	case OutputJSON:
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(o.w, "%s\n", data)
+		return err
+
+	case OutputJSONL:
+		return json.NewEncoder(o.w).Encode(r)
+
+	default:
+		var expected, expectedOperator string
+		if r.ExpectedProposer != nil {
+			expected = r.ExpectedProposer.Address.Hex()
+			expectedOperator = r.ExpectedProposer.Operator
+		}
+		signers, signersOperators := joinValidators(r.Signers)
+		missing, missingOperators := joinValidators(r.Missing)
+
+		o.csv.Write([]string{
+			strconv.FormatInt(r.Number, 10), r.Hash.Hex(), strconv.FormatUint(r.Timestamp, 10), r.Time.Format(time.RFC3339), strconv.FormatUint(r.Interval, 10),
+			r.Proposer.Address.Hex(), r.Proposer.Operator, expected, expectedOperator, strconv.FormatBool(r.MissedTurn),
+			signers, signersOperators, missing, missingOperators,
+			strconv.FormatUint(r.GasLimit, 10), strconv.FormatUint(r.GasUsed, 10),
+		})
+		o.csv.Flush()
+		return o.csv.Error()
+
+	}
+}
+
+// joinValidators returns the addresses and the operators of the list, separated by ';'
+func joinValidators(list []Validator) (string, string) {
+	addresses := make([]string, len(list))
+	operators := make([]string, len(list))
+	for i, v := range list {
+		addresses[i] = v.Address.Hex()
+		operators[i] = v.Operator
+	}
+	return strings.Join(addresses, ";"), strings.Join(operators, ";")
+}
@@ -0,0 +1,97 @@
+package redt
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethertypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBlockReport() *BlockReport {
+	r := &BlockReport{
+		Number:    100,
+		Hash:      common.HexToHash("0xabcd"),
+		Timestamp: 1660000000,
+		Time:      time.Unix(1660000000, 0).UTC(),
+		Interval:  3,
+		GasLimit:  700000000,
+		GasUsed:   21000,
+		Proposer:  Validator{Address: common.HexToAddress("0x1111111111111111111111111111111111111111")},
+		Signers: []Validator{
+			{Address: common.HexToAddress("0x1111111111111111111111111111111111111111")},
+			{Address: common.HexToAddress("0x3333333333333333333333333333333333333333")},
+		},
+		Missing: []Validator{{Address: common.HexToAddress("0x2222222222222222222222222222222222222222")}},
+	}
+	r.setExpectedProposer(Validator{Address: common.HexToAddress("0x2222222222222222222222222222222222222222")})
+	return r
+}
+
+func TestOutputJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	o, err := NewOutputDisplay(OutputJSONL, &buf)
+	assert.NoError(t, err)
+
+	o.BlockProcessed(testBlockReport())
+	o.BlockProcessed((&RedTNode{}).newBlockReport(&ethertypes.Header{Number: big.NewInt(101)}, common.Address{}, nil))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var r map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &r))
+	assert.Equal(t, float64(100), r["number"])
+	assert.Equal(t, "2022-08-08T23:06:40Z", r["time"])
+	assert.Equal(t, true, r["missedTurn"])
+	assert.Len(t, r["signers"], 2)
+	assert.Len(t, r["missing"], 1)
+	assert.Equal(t, "0x2222222222222222222222222222222222222222", r["expectedProposer"].(map[string]any)["address"])
+
+	// The lists are empty instead of null, and the unknown expected proposer is null
+	assert.Contains(t, lines[1], `"expectedProposer":null`)
+	assert.Contains(t, lines[1], `"signers":[]`)
+	assert.Contains(t, lines[1], `"missing":[]`)
+}
+
+func TestOutputJSON(t *testing.T) {
+	var buf bytes.Buffer
+	o, err := NewOutputDisplay(OutputJSON, &buf)
+	assert.NoError(t, err)
+
+	o.BlockProcessed(testBlockReport())
+
+	var r BlockReport
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &r))
+	assert.Equal(t, int64(100), r.Number)
+	assert.Equal(t, uint64(21000), r.GasUsed)
+}
+
+func TestOutputCSV(t *testing.T) {
+	var buf bytes.Buffer
+	o, err := NewOutputDisplay(OutputCSV, &buf)
+	assert.NoError(t, err)
+
+	o.Start(nil)
+	o.BlockProcessed(testBlockReport())
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Len(t, records[1], len(csvHeader))
+	assert.Equal(t, "100", records[1][0])
+	assert.Equal(t, "true", records[1][9])
+	assert.Equal(t, "0x1111111111111111111111111111111111111111;0x3333333333333333333333333333333333333333", records[1][10])
+}
+
+func TestOutputUnknownFormat(t *testing.T) {
+	_, err := NewOutputDisplay("xml", &bytes.Buffer{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package redt
+
+import (
+	"fmt"
+
+	ethertypes "github.com/ethereum/go-ethereum/core/types"
+	qtypes "github.com/hesusruiz/signers/types"
+	"github.com/rs/zerolog/log"
+)
+
+// ProcessNewBlocks processes the blocks notified by the channel, until it is closed. The blocks skipped
+// since the last one processed are processed first, as they can be skipped because the client reconnected
+// or by the polling interval. The process function must include the block in the statistics, so each
+// block is processed once. If a block fails, the error is logged and it is retried with the next head.
+func (rt *RedTNode) ProcessNewBlocks(inputCh <-chan qtypes.RawHeader, process func(header *ethertypes.Header) error) {
+
+	for head := range inputCh {
+		if err := rt.processBlocksUntil(rt.nextBlockToProcess(int64(head.Number)), int64(head.Number), process); err != nil {
+			log.Error().Err(err).Msg("processing blocks")
+		}
+	}
+}
+
+// processBlocksUntil retrieves and processes the blocks from the given one until the head, in order.
+// It stops at the first block which fails.
+func (rt *RedTNode) processBlocksUntil(from int64, head int64, process func(header *ethertypes.Header) error) error {
+
+	for number := from; number <= head; number++ {
+		header, err := rt.HeaderByNumber(number)
+		if err == nil {
+			err = process(header)
+		}
+		if err != nil {
+			return fmt.Errorf("block %v: %w", number, err)
+		}
+	}
+
+	return nil
+}
+
+// nextBlockToProcess returns the first block to process when a new head is received: the one after
+// the last block processed, so the blocks skipped are processed too, or the head if none was processed
+func (rt *RedTNode) nextBlockToProcess(head int64) int64 {
+
+	last := rt.LastBlockProcessed()
+	if last == noBlockProcessed {
+		return head
+	}
+
+	from := last + 1
+	if head > from {
+		log.Warn().Int64("from", from).Int64("to", head-1).Msg("processing the blocks skipped")
+	}
+	return from
+}
+
+// includeHeader includes the block in the statistics, which notifies the observers
+func (rt *RedTNode) includeHeader(header *ethertypes.Header) error {
+	_, _, err := rt.UpdateStatisticsForBlock(header)
+	return err
+}
@@ -0,0 +1,73 @@
+package redt
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethertypes "github.com/ethereum/go-ethereum/core/types"
+	lrucache "github.com/hashicorp/golang-lru"
+	qtypes "github.com/hesusruiz/signers/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextBlockToProcess(t *testing.T) {
+
+	// Without any block processed, the processing starts at the head
+	rt := &RedTNode{lastBlockProcessed: noBlockProcessed}
+	assert.Equal(t, int64(7), rt.nextBlockToProcess(7))
+
+	// Even if the statistics start at the genesis block
+	rt.lastBlockProcessed = 0
+	assert.Equal(t, int64(1), rt.nextBlockToProcess(7))
+
+	// The blocks skipped are processed too
+	rt.lastBlockProcessed = 4
+	assert.Equal(t, int64(5), rt.nextBlockToProcess(7))
+}
+
+func TestProcessNewBlocks(t *testing.T) {
+	a := common.HexToAddress("0x01")
+
+	cache, err := lrucache.New(20)
+	assert.NoError(t, err)
+	for number := int64(1); number <= 12; number++ {
+		cache.Add(number, &ethertypes.Header{Number: big.NewInt(number), Time: uint64(number)})
+	}
+
+	rt := &RedTNode{
+		headerCache:        cache,
+		valSet:             []common.Address{a},
+		asProposer:         map[common.Address]int{},
+		asSigner:           map[common.Address]int{},
+		missedSeals:        map[common.Address]int{},
+		missedTurns:        map[common.Address]int{},
+		lastBlockProcessed: noBlockProcessed,
+	}
+
+	// Block 7 fails once, so it is retried with the next head
+	var processed []int64
+	failed := false
+	process := func(header *ethertypes.Header) error {
+		if header.Number.Int64() == 7 && !failed {
+			failed = true
+			return errors.New("unavailable")
+		}
+		rt.updateStatistics(header, a, nil)
+		processed = append(processed, header.Number.Int64())
+		return nil
+	}
+
+	// Heads repeated, skipped and received out of order
+	inputCh := make(chan qtypes.RawHeader, 10)
+	for _, head := range []uint64{3, 3, 5, 4, 8, 9, 12} {
+		inputCh <- qtypes.RawHeader{Number: qtypes.HexNumber(head)}
+	}
+	close(inputCh)
+
+	rt.ProcessNewBlocks(inputCh, process)
+
+	assert.Equal(t, []int64{3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, processed)
+	assert.Equal(t, 10, rt.asProposer[a])
+}
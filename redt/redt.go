@@ -30,11 +30,11 @@ import (
 )
 
 type ValInfo struct {
-	Operator   string `json:"operator"`
-	Enode      string `json:"enode"`
-	Address    common.Address
-	Signatures int
-	Proposals  int
+	Operator   string         `json:"operator" yaml:"operator"`
+	Enode      string         `json:"enode" yaml:"enode"`
+	Address    common.Address `yaml:"-"`
+	Signatures int            `yaml:"-"`
+	Proposals  int            `yaml:"-"`
 }
 
 var enodes = []*ValInfo{
@@ -103,14 +103,40 @@ type RedTNode struct {
 	allValidators      map[common.Address]*ValInfo
 	asProposer         map[common.Address]int
 	asSigner           map[common.Address]int
+	missedSeals        map[common.Address]int
+	missedTurns        map[common.Address]int
+	lastAuthor         common.Address
+	lastBlockTime      uint64
 	lastBlockProcessed int64
+	windows            []*windowState
+	observersLock      sync.Mutex
+	observers          []BlockObserver
 	spinner            *pterm.SpinnerPrinter
+	saver              *stateSaver
+}
+
+// dial connects with the transport inferred from the url, so paths are always IPC sockets
+func dial(url string) (*rpc.Client, error) {
+	transport, err := Transport(url)
+	if err != nil {
+		return nil, err
+	}
+	if transport == TransportIPC {
+		return rpc.DialIPC(context.Background(), url)
+	}
+	return rpc.Dial(url)
 }
 
 func NewRedTNode(url string) (*RedTNode, error) {
+	return NewRedTNodeWithRegistry(url, enodes)
+}
+
+// NewRedTNodeWithRegistry connects to a node of a network with its own registry of validators,
+// which is used to know the operators of the validators
+func NewRedTNodeWithRegistry(url string, registry []*ValInfo) (*RedTNode, error) {
 
 	// Connect to Client
-	rpccli, err := rpc.Dial(url)
+	rpccli, err := dial(url)
 	if err != nil {
 		return nil, err
 	}
@@ -124,28 +150,41 @@ func NewRedTNode(url string) (*RedTNode, error) {
 	// Restarting the program loads the most recent Validator set
 	rt.valSet, err = rt.getValSet()
 	if err != nil {
-		panic(err)
+		rpccli.Close()
+		return nil, err
 	}
 
 	// Calculate the full validator list including the ones not currently in the valSet
 	// Initialise Validators map
-	rt.allValidators = make(map[common.Address]*ValInfo, len(enodes))
-	for _, item := range enodes {
-		en := enode.MustParse(item.Enode)
+	rt.allValidators = make(map[common.Address]*ValInfo, len(registry))
+	for _, item := range registry {
+		en, err := enode.ParseV4(item.Enode)
+		if err != nil {
+			rpccli.Close()
+			return nil, fmt.Errorf("enode of %v: %w", item.Operator, err)
+		}
 		address := crypto.PubkeyToAddress(*en.Pubkey())
 
-		item.Address = address
+		// Copy the item, because the registry may be shared by several nodes
+		info := *item
+		info.Address = address
 
-		rt.allValidators[address] = item
+		rt.allValidators[address] = &info
 	}
 
 	// Initialise the counters for validators/signers
 	rt.asProposer = map[common.Address]int{}
 	rt.asSigner = map[common.Address]int{}
+	rt.missedSeals = map[common.Address]int{}
+	rt.missedTurns = map[common.Address]int{}
+	rt.SetWindows(defaultWindows)
+	rt.lastBlockProcessed = noBlockProcessed
 
 	for _, addr := range rt.valSet {
 		rt.asProposer[addr] = 0
 		rt.asSigner[addr] = 0
+		rt.missedSeals[addr] = 0
+		rt.missedTurns[addr] = 0
 	}
 
 	// Initialise the header cache
@@ -165,77 +204,130 @@ func (rt *RedTNode) RpcClient() *rpc.Client {
 	return rt.rpccli
 }
 
-func (rt *RedTNode) InitializeStats(numBlocks int64) {
+// InitializeStats resets the counters and includes the given number of past blocks in the statistics.
+// If a block can not be included, the statistics stop at the previous one and the error is returned.
+func (rt *RedTNode) InitializeStats(numBlocks int64) error {
 
-	// Only us
-	rt.countersLock.Lock()
-	defer rt.countersLock.Unlock()
+	// Get the current block header
+	header, err := rt.HeaderByNumber(-1)
+	if err != nil {
+		return err
+	}
+	currentNumber := header.Number.Int64()
+
+	// Calculate the ancient block where calculation starts, after the genesis block which has no signers
+	oldNumber := currentNumber - numBlocks
+	if oldNumber < 0 {
+		oldNumber = 0
+	}
 
-	// Reset counters for all Validators
+	// Reset counters for all Validators. The lock is released before processing the blocks,
+	// because UpdateStatisticsForBlock acquires it for each block
+	rt.countersLock.Lock()
 	for _, addr := range rt.valSet {
 		rt.asProposer[addr] = 0
 		rt.asSigner[addr] = 0
+		rt.missedSeals[addr] = 0
+		rt.missedTurns[addr] = 0
 	}
+	rt.lastAuthor = common.Address{}
+	rt.lastBlockTime = 0
+	rt.lastBlockProcessed = oldNumber
+	rt.resetWindows()
+	rt.countersLock.Unlock()
 
 	// Short-circuit if no work
 	if numBlocks <= 0 {
-		return
+		return nil
 	}
 
-	// Get the current block header
-	header, err := rt.HeaderByNumber(-1)
+	// This also stores the number processed, so several threads in parallel do not alter the statistics
+	return rt.processBlocksUntil(oldNumber+1, currentNumber, rt.includeHeader)
+}
+
+// UpdateStatisticsForBlock includes the block in the statistics and returns its author and signers.
+// The block is not included if its signers can not be retrieved.
+func (rt *RedTNode) UpdateStatisticsForBlock(header *ethertypes.Header) (author common.Address, signers []common.Address, err error) {
+
+	author, signers, err = SignersFromBlock(header)
 	if err != nil {
-		log.Fatal().Err(err).Msg("")
+		return author, signers, err
 	}
-	currentNumber := header.Number.Int64()
 
-	// Calculate the ancient block where calculation starts
-	oldNumber := currentNumber - numBlocks
+	rt.updateStatistics(header, author, signers)
 
-	for i := oldNumber; i <= currentNumber; i++ {
+	return author, signers, nil
 
-		// Get block header data
-		header, err = rt.HeaderByNumber(i)
-		if err != nil {
-			log.Fatal().Err(err).Msg("")
-		}
+}
 
-		rt.UpdateStatisticsForBlock(header)
+// ProcessHeader includes the block in the statistics and returns its report,
+// or nil if the block was already processed
+func (rt *RedTNode) ProcessHeader(header *ethertypes.Header) (*BlockReport, error) {
 
+	author, signers, err := SignersFromBlock(header)
+	if err != nil {
+		return nil, err
 	}
 
-	// Store the current number so several threads in parallel do not alter the statistics
-	rt.lastBlockProcessed = currentNumber
-
+	return rt.updateStatistics(header, author, signers), nil
 }
 
-func (rt *RedTNode) UpdateStatisticsForBlock(header *ethertypes.Header) (author common.Address, signers []common.Address, err error) {
-
-	author, signers, err = SignersFromBlock(header)
-	if err != nil {
-		log.Fatal().Err(err).Msg("")
-	}
+// updateStatistics includes the block in the counters and notifies the observers.
+// It returns the report notified, or nil if the block was already processed.
+func (rt *RedTNode) updateStatistics(header *ethertypes.Header, author common.Address, signers []common.Address) *BlockReport {
 
 	// Only us
 	rt.countersLock.Lock()
-	defer rt.countersLock.Unlock()
 
 	// Check if the block was already processed
 	thisBlockNumber := header.Number.Int64()
 	if thisBlockNumber <= rt.lastBlockProcessed {
-		return author, signers, nil
+		rt.countersLock.Unlock()
+		return nil
 	}
+	isConsecutive := thisBlockNumber == rt.lastBlockProcessed+1
+	rt.lastBlockProcessed = thisBlockNumber
+
+	// The report for the observers
+	report := rt.newBlockReport(header, author, signers)
+	if isConsecutive && rt.lastBlockTime > 0 {
+		report.Interval = header.Time - rt.lastBlockTime
+	}
+	rt.lastBlockTime = header.Time
 
 	// Increment the counter for authors
 	rt.asProposer[author] += 1
 
+	// If the author is not the one expected by the round-robin algorithm,
+	// the expected one missed its turn. We only know it if we saw the previous block
+	if isConsecutive && rt.lastAuthor != (common.Address{}) {
+		expected := rt.nextProposer(rt.lastAuthor)
+		if author != expected {
+			rt.missedTurns[expected] += 1
+		}
+		report.setExpectedProposer(rt.validator(expected))
+	}
+	rt.lastAuthor = author
+
 	// Increment counters for signers
 	for _, seal := range signers {
-		// Increment the counter of signatures
 		rt.asSigner[seal] += 1
 	}
 
-	return author, signers, err
+	// Increment counters for validators which did not sign
+	for _, val := range report.Missing {
+		rt.missedSeals[val.Address] += 1
+	}
+
+	rt.updateWindows(report)
+	rt.addCounters(report)
+
+	rt.countersLock.Unlock()
+
+	// Tell the observers, outside the lock so they can read the counters
+	rt.notifyObservers(report)
+
+	return report
 
 }
 
@@ -254,10 +346,12 @@ func (rt *RedTNode) HeaderByNumber(number int64) (*ethertypes.Header, error) {
 	defer cancel()
 
 	err := rt.rpccli.CallContext(ctx, &head, "eth_getBlockByNumber", toBlockNumArg(number), false)
-	if err == nil && head == nil {
-		err = ethereum.NotFound
+	if err != nil {
 		return nil, err
 	}
+	if head == nil {
+		return nil, ethereum.NotFound
+	}
 
 	// Add it to the cache
 	rt.headerCache.Add(head.Number.Int64(), head)
@@ -265,6 +359,25 @@ func (rt *RedTNode) HeaderByNumber(number int64) (*ethertypes.Header, error) {
 	return head, err
 }
 
+// BlocksBehind returns how many blocks our node is behind the highest block known by its peers.
+// It is zero when the node is not syncing.
+func (rt *RedTNode) BlocksBehind() (int64, error) {
+
+	// We are going to call the Geth API, with a timeout of 30 seconds
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	progress, err := rt.cli.SyncProgress(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if progress == nil {
+		return 0, nil
+	}
+
+	return int64(progress.HighestBlock) - int64(progress.CurrentBlock), nil
+}
+
 func (rt *RedTNode) CurrentBlockNumber() (int64, error) {
 	header, err := rt.HeaderByNumber(-1)
 	if err != nil {
@@ -306,13 +419,164 @@ func (rt *RedTNode) Peers() ([]*p2p.PeerInfo, error) {
 }
 
 func (rt *RedTNode) Validators() []common.Address {
+	rt.countersLock.RLock()
+	defer rt.countersLock.RUnlock()
 	return rt.valSet
 }
 
+// RefreshValidators reloads the validator set from the node, and returns the validators
+// added and removed since the last time it was loaded
+func (rt *RedTNode) RefreshValidators() (added []common.Address, removed []common.Address, err error) {
+
+	valSet, err := rt.getValSet()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rt.countersLock.Lock()
+	defer rt.countersLock.Unlock()
+
+	current := map[common.Address]bool{}
+	for _, addr := range rt.valSet {
+		current[addr] = true
+	}
+
+	updated := map[common.Address]bool{}
+	for _, addr := range valSet {
+		updated[addr] = true
+		if !current[addr] {
+			added = append(added, addr)
+		}
+	}
+
+	for _, addr := range rt.valSet {
+		if !updated[addr] {
+			removed = append(removed, addr)
+		}
+	}
+
+	// The counters of removed validators are kept, in case they are added again
+	rt.valSet = valSet
+
+	return added, removed, nil
+}
+
 func (rt *RedTNode) ValidatorInfo(validator common.Address) *ValInfo {
 	return rt.allValidators[validator]
 }
 
+// KnownValidators returns the registry of validators with their operators and enodes,
+// including the ones not in the current validator set
+func (rt *RedTNode) KnownValidators() []*ValInfo {
+	known := make([]*ValInfo, 0, len(rt.allValidators))
+	for _, item := range rt.allValidators {
+		known = append(known, item)
+	}
+	sort.Slice(known, func(i, j int) bool { return known[i].Operator < known[j].Operator })
+	return known
+}
+
+// OperatorName returns the name of the operator of the validator, or an empty string if unknown
+func (rt *RedTNode) OperatorName(validator common.Address) string {
+	item := rt.allValidators[validator]
+	if item == nil {
+		return ""
+	}
+	return item.Operator
+}
+
+// displayName is the name of the operator of the validator, or its address if it is not in the registry
+func (rt *RedTNode) displayName(validator common.Address) string {
+	if name := rt.OperatorName(validator); len(name) > 0 {
+		return name
+	}
+	return validator.Hex()
+}
+
+// noBlockProcessed is the last block processed before the statistics are initialized
+const noBlockProcessed = -1
+
+// LastBlockProcessed returns the number of the most recent block included in the statistics,
+// or -1 if none was included yet
+func (rt *RedTNode) LastBlockProcessed() int64 {
+	rt.countersLock.RLock()
+	defer rt.countersLock.RUnlock()
+	return rt.lastBlockProcessed
+}
+
+// nextProposer returns the validator that should propose the block after the one proposed by author,
+// according to the round-robin selection algorithm
+func (rt *RedTNode) nextProposer(author common.Address) common.Address {
+	var nextIndex int
+	for i := 0; i < len(rt.valSet); i++ {
+		if author == rt.valSet[i] {
+			nextIndex = (i + 1) % len(rt.valSet)
+			break
+		}
+	}
+	return rt.valSet[nextIndex]
+}
+
+// NextProposer returns the validator expected to propose the next block, or the zero address
+// if no block has been processed yet
+func (rt *RedTNode) NextProposer() common.Address {
+	rt.countersLock.RLock()
+	defer rt.countersLock.RUnlock()
+
+	if rt.lastAuthor == (common.Address{}) || len(rt.valSet) == 0 {
+		return common.Address{}
+	}
+	return rt.nextProposer(rt.lastAuthor)
+}
+
+// QuorumSize returns the minimum number of committed seals required for a block,
+// which in IBFT is ceil(2N/3) for a validator set of N
+func (rt *RedTNode) QuorumSize() int {
+	n := len(rt.valSet)
+	return (2*n + 2) / 3
+}
+
+// ValidatorStats are the counters accumulated for a validator since the program started,
+// and the ones in the rolling windows of recent blocks
+type ValidatorStats struct {
+	Address     common.Address `json:"address"`
+	Operator    string         `json:"operator"`
+	Proposals   int            `json:"proposals"`
+	Seals       int            `json:"seals"`
+	MissedSeals int            `json:"missedSeals"`
+	MissedTurns int            `json:"missedTurns"`
+	Windows     []WindowStats  `json:"windows,omitempty"`
+}
+
+// Stats returns a snapshot of the counters of the validators in the current set,
+// in the same order as the validator set
+func (rt *RedTNode) Stats() []ValidatorStats {
+
+	// Lock for reading
+	rt.countersLock.RLock()
+	defer rt.countersLock.RUnlock()
+
+	return rt.stats()
+}
+
+// stats must be called with the counters lock held
+func (rt *RedTNode) stats() []ValidatorStats {
+	stats := make([]ValidatorStats, len(rt.valSet))
+	for i, addr := range rt.valSet {
+		stats[i] = ValidatorStats{
+			Address:     addr,
+			Operator:    rt.OperatorName(addr),
+			Proposals:   rt.asProposer[addr],
+			Seals:       rt.asSigner[addr],
+			MissedSeals: rt.missedSeals[addr],
+			MissedTurns: rt.missedTurns[addr],
+			Windows:     rt.windowStats(addr),
+		}
+	}
+
+	return stats
+}
+
 func (rt *RedTNode) DisplayMyInfo() {
 
 	ni, err := rt.NodeInfo()
@@ -391,12 +655,12 @@ func SignersFromBlock(header *ethertypes.Header) (author common.Address, signers
 	// Retrieve the signature from the header extra-data
 	extra, err := ethertypes.ExtractIstanbulExtra(header)
 	if err != nil {
-		log.Fatal().Err(err).Msg("")
+		return author, nil, err
 	}
 
 	author, err = istanbul.GetSignatureAddress(sigHash(header).Bytes(), extra.Seal)
 	if err != nil {
-		log.Fatal().Err(err).Msg("")
+		return author, nil, err
 	}
 
 	committedSeal := extra.CommittedSeal
@@ -407,7 +671,7 @@ func SignersFromBlock(header *ethertypes.Header) (author common.Address, signers
 		// Get the original address by seal and parent block hash
 		addr, err := istanbul.GetSignatureAddress(proposalSeal, seal)
 		if err != nil {
-			log.Fatal().Err(err).Msg("")
+			return author, nil, err
 		}
 		signers = append(signers, addr)
 	}
@@ -415,467 +679,156 @@ func SignersFromBlock(header *ethertypes.Header) (author common.Address, signers
 	return
 }
 
-func (rt *RedTNode) DisplaySignersForBlockNumber(number int64, latestTimestamp uint64) uint64 {
+// DisplaySignersForBlockNumber includes the block in the statistics and prints a box with its report,
+// followed by a spinner until the next block is received
+func (rt *RedTNode) DisplaySignersForBlockNumber(number int64) error {
 
-	if rt.spinner != nil && rt.spinner.IsActive {
-		rt.spinner.Stop()
-	}
-
-	// Get the block timestamp with the specified number
 	currentHeader, err := rt.HeaderByNumber(number)
 	if err != nil {
-		log.Fatal().Err(err).Msg("")
-	}
-	currentTimestamp := currentHeader.Time
-
-	// Calculate the elapsed time with respect to the latest one we received
-	elapsed := currentTimestamp - latestTimestamp
-
-	// Update the statistics in memory
-	author, signers, err := rt.UpdateStatisticsForBlock(currentHeader)
-	if err != nil {
-		log.Fatal().Err(err).Msg("")
-	}
-
-	// Get the name of the node operator
-	oper := rt.ValidatorInfo(author)
-
-	// Determine the next node that should be proposer, according to the round-robin
-	// selection algorithm
-	var nextIndex int
-	for i := 0; i < len(rt.valSet); i++ {
-		if author == rt.valSet[i] {
-			nextIndex = (i + 1) % len(rt.valSet)
-			break
-		}
+		return err
 	}
-	nextProposer := rt.valSet[nextIndex]
-
-	// Get the name of the node operator
-	nextProposerOperator := rt.ValidatorInfo(nextProposer).Operator
 
-	t := time.Unix(int64(currentTimestamp), 0)
+	return rt.displayHeader(currentHeader)
+}
 
-	blockInfo := pterm.DefaultBox.WithTitle("Block")
+// displayHeader includes the block in the statistics and prints a box with its report
+func (rt *RedTNode) displayHeader(currentHeader *ethertypes.Header) error {
 
-	// Build the header message, in red if block time was bad
-	headerMsg1 := pterm.Sprintf("%v (%v sec) %v\n", number, elapsed, t)
-	if elapsed > 5 {
-		headerMsg1 = pterm.Red(headerMsg1)
+	if rt.spinner != nil && rt.spinner.IsActive {
+		rt.spinner.Stop()
 	}
 
-	// The author info
-	headerMsg2 := pterm.Sprintf("Author: %v (%v) (%v)\n", oper.Operator, rt.asProposer[author], author)
-
-	// Gas limit and number of txs
-	headerMsg3 := pterm.Sprintf("GasLimit: %v GasUsed: %v\n", currentHeader.GasLimit, currentHeader.GasUsed)
-
-	var currentSigners = map[common.Address]bool{}
-
-	for _, seal := range signers {
-		currentSigners[seal] = true
+	// Update the statistics in memory
+	report, err := rt.ProcessHeader(currentHeader)
+	if err != nil {
+		return err
 	}
-
-	tableMsg := ""
-
-	// Print the title of the table
-	tableMsg += pterm.Sprintf("\n  Author |  Signer  |       Name      Address")
-
-	for _, val := range rt.Validators() {
-
-		item := rt.ValidatorInfo(val)
-
-		authorCount := rt.asProposer[item.Address]
-
-		var authorCountStr string
-		if authorCount == 0 {
-			authorCountStr = pterm.FgRed.Sprintf("%6v", authorCount)
-		} else {
-			authorCountStr = pterm.Sprintf("%6v", authorCount)
-		}
-
-		var currentAuthorStr string
-		if author.Hex() == item.Address.Hex() {
-			currentAuthorStr = pterm.BgLightBlue.Sprint(pterm.Bold.Sprintf("%v %1v", authorCountStr, "X"))
-		} else {
-			currentAuthorStr = pterm.Bold.Sprintf("%v %1v", authorCountStr, " ")
-		}
-
-		signerCount := rt.asSigner[item.Address]
-
-		var signerCountStr string
-		if signerCount == 0 {
-			signerCountStr = pterm.FgRed.Sprintf("%6v", signerCount)
-		} else {
-			signerCountStr = pterm.Sprintf("%6v", signerCount)
-		}
-
-		var currentSignerStr string
-		if currentSigners[item.Address] {
-			currentSignerStr = pterm.BgLightBlue.Sprint(pterm.Bold.Sprintf("%v %1v", signerCountStr, "X"))
-		} else {
-			currentSignerStr = pterm.Bold.Sprintf("%v %1v", signerCountStr, " ")
+	if report == nil {
+		// Already processed, it is shown with the current counters
+		report, err = rt.ReportForHeader(currentHeader, 0)
+		if err != nil {
+			return err
 		}
-
-		tableMsg += pterm.Sprintf("\n%v | %v | %12v %v", currentAuthorStr, currentSignerStr, item.Operator, item.Address)
-
 	}
 
-	blockInfo.Println(headerMsg1 + headerMsg2 + headerMsg3 + tableMsg)
+	pterm.DefaultBox.WithTitle("Block").Println(report.Terminal())
 
-	rt.spinner, _ = pterm.DefaultSpinner.Start("Waiting for ", nextProposerOperator, " to create next block ...")
+	next := ""
+	if report.NextProposer != nil {
+		next = report.NextProposer.Name()
+	}
+	rt.spinner, _ = pterm.DefaultSpinner.Start("Waiting for ", next, " to create next block ...")
 	rt.spinner.RemoveWhenDone = true
 
-	return currentTimestamp
-
-}
-
-type SignerData struct {
-	Proposer string
-	Signers  []string
+	return nil
 }
 
-func (rt *RedTNode) SignerDataForBlockNumber(number int64) (*ethertypes.Header, *SignerData, error) {
-
-	data := &SignerData{}
-
-	// Get the block timestamp with the specified number
-	header, err := rt.HeaderByNumber(number)
-	if err != nil {
-		return nil, nil, err
-	}
+// MonitorSigners displays the signers of the blocks, polling the node with the refresh interval.
+// With a state file, the counters continue from the ones saved by a previous run.
+// The counters of the rolling windows are shown next to the cumulative ones.
+// Without display, a box is printed for each block.
+func MonitorSigners(url string, numBlocks int64, refresh int64, stateFile string, windows []Window, display Display, observers ...BlockObserver) error {
 
-	author, signers, err := SignersFromBlock(header)
+	// Connect to the RedT node
+	rt, err := NewRedTNode(url)
 	if err != nil {
-		return nil, nil, err
-	}
-
-	data.Signers = make([]string, len(signers))
-
-	data.Proposer = author.String()
-	for i, item := range signers {
-		data.Signers[i] = item.String()
+		return err
 	}
+	rt.SetWindows(windows)
 
-	return header, data, nil
-
+	return rt.monitor(NewPollingSource(rt, time.Duration(refresh)*time.Second), numBlocks, stateFile, display, observers)
 }
 
-func (rt *RedTNode) SignersForHeader(header *ethertypes.Header, latestTimestamp uint64) (map[string]any, uint64) {
-
-	data := make(map[string]any)
-
-	currentTimestamp := header.Time
+// MonitorSignersWS displays the signers of the blocks, receiving them via WebSockets subscriptions.
+// The statistics start with the given number of past blocks, and the blocks missed while the client
+// reconnects are processed when the next one is received. With a state file, the counters continue from
+// the ones saved by a previous run. The counters of the rolling windows are shown next to the cumulative
+// ones. Without display, a box is printed for each block.
+func MonitorSignersWS(url string, numBlocks int64, stateFile string, windows []Window, display Display, observers ...BlockObserver) error {
 
-	// Calculate the elapsed time with respect to the latest one we received
-	elapsed := currentTimestamp - latestTimestamp
-
-	// Update the statistics in memory
-	author, signers, err := rt.UpdateStatisticsForBlock(header)
+	// Connect to the RedT node
+	rt, err := NewRedTNode(url)
 	if err != nil {
-		log.Fatal().Err(err).Msg("")
+		return err
 	}
+	rt.SetWindows(windows)
 
-	// Get the name of the node operator
-	oper := rt.ValidatorInfo(author)
-
-	// Determine the next node that should be proposer, according to the round-robin
-	// selection algorithm
-	var nextIndex int
-	for i := 0; i < len(rt.valSet); i++ {
-		if author == rt.valSet[i] {
-			nextIndex = (i + 1) % len(rt.valSet)
-			break
-		}
-	}
-	nextProposer := rt.valSet[nextIndex]
-
-	// Get the name of the node operator
-	nextProposerOperator := rt.ValidatorInfo(nextProposer).Operator
-
-	t := time.Unix(int64(currentTimestamp), 0)
-
-	// Lock for reading
-	rt.countersLock.RLock()
-	defer rt.countersLock.RUnlock()
-
-	data["number"] = header.Number
-	data["elapsed"] = elapsed
-	data["timestamp"] = t
-	data["operator"] = oper.Operator
-	data["authorCount"] = rt.asProposer[author]
-	data["authorAddress"] = author
-	data["nextProposerOperator"] = nextProposerOperator
-
-	data["gasLimit"] = header.GasLimit
-	data["gasUsed"] = header.GasUsed
-
-	var currentSigners = map[common.Address]bool{}
-
-	for _, seal := range signers {
-		currentSigners[seal] = true
-	}
-
-	st := make([]map[string]any, len(rt.Validators()))
-
-	for i, val := range rt.Validators() {
-
-		d := make(map[string]any)
-
-		item := rt.ValidatorInfo(val)
-
-		authorCount := rt.asProposer[item.Address]
-
-		authorCountStr := fmt.Sprintf("%v", authorCount)
-		if authorCount == 0 {
-			authorCountStr = fmt.Sprintf("<span class='w3-badge w3-red'>%v</span>", authorCount)
-		}
-		if author.Hex() == item.Address.Hex() {
-			authorCountStr = fmt.Sprintf("<span class='w3-badge'>%v</span>", authorCount)
-		}
-
-		d["authorCount"] = authorCountStr
-
-		signerCount := rt.asSigner[item.Address]
-
-		signerCountStr := fmt.Sprintf("%v", signerCount)
-		if signerCount == 0 {
-			signerCountStr = fmt.Sprintf("<span class='w3-badge w3-red'>%v</span>", signerCount)
-		}
-		if currentSigners[item.Address] {
-			signerCountStr = fmt.Sprintf("<span class='w3-badge'>%v</span>", signerCount)
-		}
-
-		d["signerCount"] = signerCountStr
-
-		d["operator"] = item.Operator
-		d["address"] = item.Address
-
-		st[i] = d
-
+	qc, err := client.NewQuorumClient(url)
+	if err != nil {
+		return err
 	}
 
-	data["signers"] = st
-
-	return data, currentTimestamp
-
+	return rt.monitor(qc, numBlocks, stateFile, display, observers)
 }
 
-/*
-	 func (rt *RedTNode) SignersForBlockNumber(number int64, latestTimestamp uint64) (map[string]any, uint64) {
-
-		data := make(map[string]any)
-
-		// Get the block timestamp with the specified number
-		currentHeader, err := rt.HeaderByNumber(number)
-		if err != nil {
-			log.Fatal().Err(err).Msg("")
-		}
-		currentTimestamp := currentHeader.Time
-
-		// Calculate the elapsed time with respect to the latest one we received
-		elapsed := currentTimestamp - latestTimestamp
-
-		// Update the statistics in memory
-		author, signers, err := rt.UpdateStatisticsForBlock(currentHeader)
-		if err != nil {
-			log.Fatal().Err(err).Msg("")
-		}
-
-		// Get the name of the node operator
-		oper := rt.ValidatorInfo(author)
-
-		// Determine the next node that should be proposer, according to the round-robin
-		// selection algorithm
-		var nextIndex int
-		for i := 0; i < len(rt.valSet); i++ {
-			if author == rt.valSet[i] {
-				nextIndex = (i + 1) % len(rt.valSet)
-				break
-			}
-		}
-		nextProposer := rt.valSet[nextIndex]
-
-		// Get the name of the node operator
-		nextProposerOperator := rt.ValidatorInfo(nextProposer).Operator
-
-		t := time.Unix(int64(currentTimestamp), 0)
-
-		data["number"] = currentHeader.Number
-		data["elapsed"] = elapsed
-		data["timestamp"] = t
-		data["operator"] = oper.Operator
-		data["authorCount"] = rt.asProposer[author]
-		data["authorAddress"] = author
-		data["nextProposerOperator"] = nextProposerOperator
-
-		data["gasLimit"] = currentHeader.GasLimit
-		data["gasUsed"] = currentHeader.GasUsed
-
-		var currentSigners = map[common.Address]bool{}
-
-		for _, seal := range signers {
-			currentSigners[seal] = true
-		}
-
-		st := make([]map[string]any, len(rt.Validators()))
-
-		for i, val := range rt.Validators() {
-
-			d := make(map[string]any)
-
-			item := rt.ValidatorInfo(val)
-
-			authorCount := rt.asProposer[item.Address]
-
-			authorCountStr := fmt.Sprintf("%v", authorCount)
-			if authorCount == 0 {
-				authorCountStr = fmt.Sprintf("<span class='w3-badge w3-red'>%v</span>", authorCount)
-			}
-			if author.Hex() == item.Address.Hex() {
-				authorCountStr = fmt.Sprintf("<span class='w3-badge'>%v</span>", authorCount)
-			}
-
-			d["authorCount"] = authorCountStr
-
-			signerCount := rt.asSigner[item.Address]
-
-			signerCountStr := fmt.Sprintf("%v", signerCount)
-			if signerCount == 0 {
-				signerCountStr = fmt.Sprintf("<span class='w3-badge w3-red'>%v</span>", signerCount)
-			}
-			if currentSigners[item.Address] {
-				signerCountStr = fmt.Sprintf("<span class='w3-badge'>%v</span>", signerCount)
-			}
-
-			d["signerCount"] = signerCountStr
-
-			d["operator"] = item.Operator
-			d["address"] = item.Address
-
-			st[i] = d
-
-		}
-
-		data["signers"] = st
-
-		return data, currentTimestamp
-
-}
-*/
-func MonitorSigners(url string, numBlocks int64, refresh int64) {
+// Watch displays the signers of the blocks, choosing the transport from the url: ws(s), http(s)
+// or the path of the IPC socket. The blocks are received via subscriptions when available, and
+// by polling the node with the refresh interval otherwise. The mode used is logged.
+// With a state file, the counters continue from the ones saved by a previous run.
+// The counters of the rolling windows are shown next to the cumulative ones.
+// Without display, a box is printed for each block.
+func Watch(url string, numBlocks int64, refresh int64, stateFile string, windows []Window, display Display, observers ...BlockObserver) error {
 
 	// Connect to the RedT node
 	rt, err := NewRedTNode(url)
 	if err != nil {
-		log.Fatal().Err(err).Msg("")
-		os.Exit(1)
-	}
-
-	rt.spinner, _ = pterm.DefaultSpinner.Start("Calculating statistics for ", numBlocks, " blocks ...")
-	rt.spinner.RemoveWhenDone = true
-
-	// Initialise statistics with historic info
-	rt.InitializeStats(numBlocks)
-
-	rt.spinner.Stop()
-
-	// Get the current block header info
-	latestHeader, err := rt.HeaderByNumber(-1)
-	if err != nil {
-		log.Fatal().Err(err).Msg("")
+		return err
 	}
-	latestNumber := latestHeader.Number.Int64()
-
-	oldNumber := latestNumber - 0
+	rt.SetWindows(windows)
 
-	// Start from an old block
-	latestHeader, err = rt.HeaderByNumber(oldNumber)
+	source, err := NewHeadSource(url, rt, time.Duration(refresh)*time.Second)
 	if err != nil {
-		log.Fatal().Err(err).Msg("")
+		return err
 	}
-	latestNumber = latestHeader.Number.Int64()
-
-	latestTimestamp := latestHeader.Time
-
-	// Display  for the first time
-	rt.DisplaySignersForBlockNumber(latestNumber, latestTimestamp)
 
-	for {
+	return rt.monitor(source, numBlocks, stateFile, display, observers)
+}
 
-		// Sleep before getting the next one
-		time.Sleep(time.Duration(refresh) * time.Second)
+// monitor runs the monitor commands: it starts the statistics, adds the observers and processes the
+// blocks notified by the source, shown in the display or, without display, printing a box for each one
+func (rt *RedTNode) monitor(source HeadSource, numBlocks int64, stateFile string, display Display, observers []BlockObserver) error {
 
-		// Get the current block number
-		currentHeader, err := rt.HeaderByNumber(-1)
-		if err != nil {
-			log.Fatal().Err(err).Msg("")
+	if display != nil {
+		// The display also receives the historic blocks
+		rt.AddObserver(display)
+		if err := rt.StartStats(numBlocks, stateFile); err != nil {
+			return err
 		}
-		currentNumber := currentHeader.Number.Int64()
-
-		// Make sure we have advanced at least one block
-		if currentNumber == latestNumber {
-			continue
-		}
-
-		// Display all blocks from the latest one until the current one
-		for i := latestNumber + 1; i <= currentNumber; i++ {
-			latestTimestamp = rt.DisplaySignersForBlockNumber(i, latestTimestamp)
+		defer rt.StopStats()
+		defer rt.stopObservers()
+		for _, o := range observers {
+			rt.AddObserver(o)
 		}
-
-		// Update the latest block number that we processed
-		latestNumber = currentNumber
-
+		return rt.runDisplay(source, display)
 	}
 
-}
-
-func MonitorSignersWS(url string, numBlocks int64) {
+	rt.spinner, _ = pterm.DefaultSpinner.Start("Calculating statistics for ", numBlocks, " blocks ...")
+	rt.spinner.RemoveWhenDone = true
 
-	// Connect to the RedT node
-	rt, err := NewRedTNode(url)
+	// Initialise statistics with historic info
+	err := rt.StartStats(numBlocks, stateFile)
+	rt.spinner.Stop()
 	if err != nil {
-		log.Fatal().Err(err).Msg("")
-		os.Exit(1)
+		return err
 	}
+	defer rt.StopStats()
+	defer rt.stopObservers()
 
-	qc, err := client.NewQuorumClient(url)
-	if err != nil {
-		log.Fatal().Err(err).Msg("")
+	// The observers only receive the new blocks, not the historic ones
+	for _, o := range observers {
+		rt.AddObserver(o)
 	}
-	defer qc.Stop()
 
 	inputCh := make(chan qtypes.RawHeader)
-
-	err = qc.SubscribeChainHead(inputCh)
+	err = source.SubscribeChainHead(inputCh)
 	if err != nil {
-		log.Fatal().Err(err).Msg("")
-	}
-
-	latestTimestamp := uint64(0)
-
-	isFirst := true
-
-	for {
-		select {
-		case header := <-inputCh:
-			if isFirst {
-				// Do not display, we just get its timestamp
-				// Get the block timestamp
-				currentHeader, err := rt.HeaderByNumber(int64(header.Number))
-				if err != nil {
-					// Log the error and retry with next block
-					log.Error().Err(err).Msg("")
-					continue
-				}
-				latestTimestamp = currentHeader.Time
-				isFirst = false
-				continue
-			}
-			latestTimestamp = rt.DisplaySignersForBlockNumber(int64(header.Number), latestTimestamp)
-		}
+		return err
 	}
+	defer source.Stop()
+	logSource(source)
 
+	rt.ProcessNewBlocks(inputCh, rt.displayHeader)
+	return nil
 }
 
 func DisplayPeersInfo(url string) {
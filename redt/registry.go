@@ -0,0 +1,39 @@
+package redt
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadRegistry reads the validators of a network from a YAML file with their operators and enodes.
+//
+//	- operator: IN2
+//	  enode: enode://0ede782b...@15.236.56.133:21000?discport=0
+func LoadRegistry(path string) ([]*ValInfo, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var registry []*ValInfo
+	err = yaml.Unmarshal(data, &registry)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %v: %w", path, err)
+	}
+
+	for i, item := range registry {
+		if len(item.Operator) == 0 || len(item.Enode) == 0 {
+			return nil, fmt.Errorf("%v: validator %v requires operator and enode", path, i+1)
+		}
+	}
+
+	return registry, nil
+}
+
+// DefaultRegistry returns the validators of the Alastria RedT network
+func DefaultRegistry() []*ValInfo {
+	return enodes
+}
@@ -0,0 +1,254 @@
+package redt
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethertypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pterm/pterm"
+)
+
+// BlockReport is a block included in the statistics, with the names of the operators and a snapshot
+// of the counters right after it. It is built once per block, and the terminal, the web pages and
+// the API, the machine-readable output and the database are rendered from it.
+// The JSON schema is stable: fields may be added but not renamed or removed.
+type BlockReport struct {
+	Number           int64             `json:"number"`
+	Hash             common.Hash       `json:"hash"`
+	Timestamp        uint64            `json:"timestamp"`
+	Time             time.Time         `json:"time"`     // The timestamp in UTC
+	Interval         uint64            `json:"interval"` // Seconds since the previous block, zero if unknown
+	GasLimit         uint64            `json:"gasLimit"`
+	GasUsed          uint64            `json:"gasUsed"`
+	Proposer         Validator         `json:"proposer"`
+	ExpectedProposer *Validator        `json:"expectedProposer"` // Null if unknown, because the previous block was not seen
+	MissedTurn       bool              `json:"missedTurn"`
+	NextProposer     *Validator        `json:"nextProposer"` // Null if the validator set is unknown
+	Signers          []Validator       `json:"signers"`
+	Missing          []Validator       `json:"missing"`            // Validators in the current set which did not sign
+	Counters         []ReportValidator `json:"counters,omitempty"` // The validators of the current set, in order, if known
+}
+
+// Validator identifies a validator in the reports
+type Validator struct {
+	Address  common.Address `json:"address"`
+	Operator string         `json:"operator"`
+}
+
+// ReportValidator is a validator of the current set in a report, with its counters after the block
+type ReportValidator struct {
+	ValidatorStats
+	Proposed bool `json:"proposed"` // It proposed the block
+	Signed   bool `json:"signed"`   // It signed the block
+}
+
+// Name is the operator of the validator, or its address if unknown
+func (v ReportValidator) Name() string {
+	return nameOf(v.Address, v.Operator)
+}
+
+// Name is the operator of the validator, or its address if unknown
+func (v Validator) Name() string {
+	return nameOf(v.Address, v.Operator)
+}
+
+func nameOf(addr common.Address, operator string) string {
+	if len(operator) > 0 {
+		return operator
+	}
+	return addr.Hex()
+}
+
+// validator identifies the validator with the name of its operator
+func (rt *RedTNode) validator(addr common.Address) Validator {
+	return Validator{Address: addr, Operator: rt.OperatorName(addr)}
+}
+
+// newBlockReport builds the report of the block with its proposer, its signers and the validators of the
+// current set which did not sign. The interval and the expected proposer are only known while processing
+// the blocks in order, so they are set by the caller. It must be called with the counters lock held.
+func (rt *RedTNode) newBlockReport(header *ethertypes.Header, author common.Address, signers []common.Address) *BlockReport {
+
+	r := &BlockReport{
+		Number:    header.Number.Int64(),
+		Hash:      header.Hash(),
+		Timestamp: header.Time,
+		Time:      time.Unix(int64(header.Time), 0).UTC(),
+		GasLimit:  header.GasLimit,
+		GasUsed:   header.GasUsed,
+		Proposer:  rt.validator(author),
+		Signers:   make([]Validator, 0, len(signers)),
+		Missing:   make([]Validator, 0),
+	}
+
+	signed := map[common.Address]bool{}
+	for _, addr := range signers {
+		r.Signers = append(r.Signers, rt.validator(addr))
+		signed[addr] = true
+	}
+	for _, val := range rt.valSet {
+		if !signed[val] {
+			r.Missing = append(r.Missing, rt.validator(val))
+		}
+	}
+
+	if len(rt.valSet) > 0 {
+		next := rt.validator(rt.nextProposer(author))
+		r.NextProposer = &next
+	}
+
+	return r
+}
+
+// setExpectedProposer records the validator whose turn it was to propose the block
+func (r *BlockReport) setExpectedProposer(expected Validator) {
+	r.ExpectedProposer = &expected
+	r.MissedTurn = expected.Address != r.Proposer.Address
+}
+
+// addCounters includes the current counters in the report if the block is the last one processed,
+// because the counters after an older block are not known. It must be called with the counters lock held.
+func (rt *RedTNode) addCounters(r *BlockReport) {
+
+	if r.Number != rt.lastBlockProcessed {
+		return
+	}
+
+	stats := rt.stats()
+	r.Counters = make([]ReportValidator, len(stats))
+	for i, st := range stats {
+		r.Counters[i] = ReportValidator{
+			ValidatorStats: st,
+			Proposed:       st.Address == r.Proposer.Address,
+			Signed:         r.Signed(st.Address),
+		}
+	}
+}
+
+// ReportForHeader builds the report of a block without including it in the statistics, for example
+// because it was already processed. The counters are included only if it is the last block processed,
+// the missing validators are the ones of the current set and the expected proposer is unknown.
+func (rt *RedTNode) ReportForHeader(header *ethertypes.Header, interval uint64) (*BlockReport, error) {
+
+	author, signers, err := SignersFromBlock(header)
+	if err != nil {
+		return nil, err
+	}
+
+	rt.countersLock.RLock()
+	defer rt.countersLock.RUnlock()
+
+	r := rt.newBlockReport(header, author, signers)
+	r.Interval = interval
+	rt.addCounters(r)
+
+	return r, nil
+}
+
+// ReportForBlockNumber retrieves the block and builds its report as ReportForHeader does, with the
+// interval since its parent. The header is also returned, as it has data which is not in the report.
+func (rt *RedTNode) ReportForBlockNumber(number int64) (*ethertypes.Header, *BlockReport, error) {
+
+	header, err := rt.HeaderByNumber(number)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var interval uint64
+	if number > 0 {
+		parent, err := rt.HeaderByNumber(number - 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		interval = header.Time - parent.Time
+	}
+
+	r, err := rt.ReportForHeader(header, interval)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return header, r, nil
+}
+
+// Signed reports whether the validator signed the block
+func (r *BlockReport) Signed(addr common.Address) bool {
+	return containsValidator(r.Signers, addr)
+}
+
+// Missed reports whether the validator is in the current set but did not sign the block
+func (r *BlockReport) Missed(addr common.Address) bool {
+	return containsValidator(r.Missing, addr)
+}
+
+// MissedTurnOf reports whether it was the turn of the validator to propose the block, but another one did
+func (r *BlockReport) MissedTurnOf(addr common.Address) bool {
+	return r.MissedTurn && r.ExpectedProposer.Address == addr
+}
+
+func containsValidator(list []Validator, addr common.Address) bool {
+	for _, v := range list {
+		if v.Address == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Addresses returns the addresses of the validators
+func Addresses(list []Validator) []common.Address {
+	addresses := make([]common.Address, len(list))
+	for i, v := range list {
+		addresses[i] = v.Address
+	}
+	return addresses
+}
+
+// Validator returns the counters of the validator after the block, or nil if it is not in the current set
+func (r *BlockReport) Validator(addr common.Address) *ReportValidator {
+	for i := range r.Counters {
+		if r.Counters[i].Address == addr {
+			return &r.Counters[i]
+		}
+	}
+	return nil
+}
+
+// Terminal formats the report for the box printed for each block in the terminal.
+// The counters of the validators are highlighted when they proposed or signed the block,
+// and in red when they are zero.
+func (r *BlockReport) Terminal() string {
+
+	// The header, in red if the block took longer than expected
+	msg := pterm.Sprintf("%v (%v sec) %v\n", r.Number, r.Interval, r.Time.Local())
+	if r.Interval > uint64(DefaultBlockPeriod/time.Second) {
+		msg = pterm.Red(msg)
+	}
+
+	proposals := 0
+	if v := r.Validator(r.Proposer.Address); v != nil {
+		proposals = v.Proposals
+	}
+	msg += pterm.Sprintf("Author: %v (%v) (%v)\n", r.Proposer.Operator, proposals, r.Proposer.Address)
+	msg += pterm.Sprintf("GasLimit: %v GasUsed: %v\n", r.GasLimit, r.GasUsed)
+
+	count := func(n int, current bool) string {
+		var s string
+		if n == 0 {
+			s = pterm.FgRed.Sprintf("%6v", n)
+		} else {
+			s = pterm.Sprintf("%6v", n)
+		}
+		if current {
+			return pterm.BgLightBlue.Sprint(pterm.Bold.Sprintf("%v %1v", s, "X"))
+		}
+		return pterm.Bold.Sprintf("%v %1v", s, " ")
+	}
+
+	msg += pterm.Sprintf("\n  Author |  Signer  |       Name      Address")
+	for _, v := range r.Counters {
+		msg += pterm.Sprintf("\n%v | %v | %12v %v", count(v.Proposals, v.Proposed), count(v.Seals, v.Signed), v.Operator, v.Address)
+	}
+
+	return msg
+}
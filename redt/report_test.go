@@ -0,0 +1,80 @@
+package redt
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethertypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockReport(t *testing.T) {
+	a := common.HexToAddress("0x01")
+	b := common.HexToAddress("0x02")
+	c := common.HexToAddress("0x03")
+
+	rt := &RedTNode{
+		valSet:             []common.Address{a, b, c},
+		allValidators:      map[common.Address]*ValInfo{a: {Operator: "Alpha"}, b: {Operator: "Beta"}},
+		asProposer:         map[common.Address]int{a: 3},
+		asSigner:           map[common.Address]int{a: 3, c: 2},
+		missedSeals:        map[common.Address]int{},
+		missedTurns:        map[common.Address]int{},
+		lastAuthor:         a,
+		lastBlockTime:      1000,
+		lastBlockProcessed: 9,
+	}
+
+	// c proposes in the turn of b, which does not sign
+	header := &ethertypes.Header{Number: big.NewInt(10), Time: 1007, GasLimit: 100, GasUsed: 10}
+	r := rt.updateStatistics(header, c, []common.Address{a, c})
+
+	assert.Equal(t, int64(10), r.Number)
+	assert.Equal(t, uint64(7), r.Interval)
+	assert.Equal(t, Validator{Address: c}, r.Proposer)
+	assert.Equal(t, &Validator{Address: b, Operator: "Beta"}, r.ExpectedProposer)
+	assert.True(t, r.MissedTurn)
+	assert.Equal(t, &Validator{Address: a, Operator: "Alpha"}, r.NextProposer)
+	assert.Equal(t, []Validator{{Address: a, Operator: "Alpha"}, {Address: c}}, r.Signers)
+	assert.Equal(t, []Validator{{Address: b, Operator: "Beta"}}, r.Missing)
+
+	// The counters include the block
+	assert.Len(t, r.Counters, 3)
+	assert.Equal(t, ReportValidator{
+		ValidatorStats: ValidatorStats{Address: c, Proposals: 1, Seals: 3},
+		Proposed:       true,
+		Signed:         true,
+	}, *r.Validator(c))
+	assert.Equal(t, ReportValidator{
+		ValidatorStats: ValidatorStats{Address: b, Operator: "Beta", MissedSeals: 1, MissedTurns: 1},
+	}, *r.Validator(b))
+	assert.Nil(t, r.Validator(common.HexToAddress("0x04")))
+	assert.Equal(t, c.Hex(), r.Validator(c).Name())
+
+	// A block already processed does not produce another report
+	assert.Nil(t, rt.updateStatistics(header, c, []common.Address{a, c}))
+
+	// The counters after an older block are not known
+	older := rt.newBlockReport(&ethertypes.Header{Number: big.NewInt(8)}, a, nil)
+	rt.addCounters(older)
+	assert.Nil(t, older.Counters)
+	assert.Equal(t, &Validator{Address: b, Operator: "Beta"}, older.NextProposer)
+
+	// The JSON schema of the machine-readable output and the API
+	data, err := json.Marshal(r)
+	assert.NoError(t, err)
+	var fields map[string]any
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, "1970-01-01T00:16:47Z", fields["time"])
+	assert.Equal(t, float64(1007), fields["timestamp"])
+	assert.Equal(t, true, fields["missedTurn"])
+	assert.Len(t, fields["counters"], 3)
+
+	// The terminal shows the operators and the interval
+	text := r.Terminal()
+	assert.Contains(t, text, "10 (7 sec)")
+	assert.Contains(t, text, "Alpha")
+	assert.Contains(t, text, "Beta")
+}
@@ -0,0 +1,350 @@
+package redt
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/hesusruiz/signers/client"
+	qtypes "github.com/hesusruiz/signers/types"
+	"github.com/rs/zerolog/log"
+)
+
+// HeadSource notifies the numbers of the new blocks added to the blockchain
+type HeadSource interface {
+	// SubscribeChainHead starts sending the new blocks to the channel
+	SubscribeChainHead(ch chan<- qtypes.RawHeader) error
+
+	// Connected reports whether the source is currently receiving data from the node
+	Connected() bool
+
+	Stop()
+}
+
+// The transports to connect to the node, inferred from the url
+const (
+	TransportWebSocket = "websocket"
+	TransportHTTP      = "http"
+	TransportIPC       = "ipc"
+)
+
+// The ways of receiving the new blocks from the node
+const (
+	ModeSubscription = "subscription"
+	ModePolling      = "polling"
+)
+
+// Transport returns the transport of the url: ws(s) and http(s) schemes, or for the IPC socket of the
+// node an absolute path or a path ending in ".ipc". Anything else without scheme is an error, because
+// an address like "localhost:8545" is not a socket.
+func Transport(url string) (string, error) {
+
+	switch {
+	case strings.HasPrefix(url, "ws://"), strings.HasPrefix(url, "wss://"):
+		return TransportWebSocket, nil
+
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+		return TransportHTTP, nil
+
+	case strings.Contains(url, "://"):
+		return "", fmt.Errorf("unsupported url scheme: %v", url)
+
+	case filepath.IsAbs(url), strings.HasSuffix(url, ".ipc"):
+		return TransportIPC, nil
+
+	default:
+		return "", fmt.Errorf("unsupported url scheme: %v", url)
+	}
+}
+
+// NewHeadSource chooses the transport from the url. The new blocks are received via subscriptions
+// when the transport and the node support them, and by polling the node with the given interval otherwise.
+func NewHeadSource(url string, rt *RedTNode, pollInterval time.Duration) (*AutoSource, error) {
+
+	transport, err := Transport(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AutoSource{
+		url:       url,
+		transport: transport,
+		rt:        rt,
+		interval:  pollInterval,
+	}, nil
+}
+
+// AutoSource is a HeadSource which selects the mode when subscribing: WebSocket urls use the
+// subscriptions of our client, IPC sockets the ones of the RPC client of the node, and HTTP urls
+// or nodes where subscribing fails use polling.
+type AutoSource struct {
+	url       string
+	transport string
+	rt        *RedTNode
+	interval  time.Duration
+
+	mu     sync.Mutex
+	active HeadSource
+	mode   string
+}
+
+// SubscribeChainHead starts sending the new blocks with the best mode available
+func (a *AutoSource) SubscribeChainHead(ch chan<- qtypes.RawHeader) error {
+
+	if a.transport != TransportHTTP {
+		source, err := a.subscriptionSource()
+		if err == nil {
+			err = source.SubscribeChainHead(ch)
+			if err != nil {
+				source.Stop()
+			}
+		}
+		if err == nil {
+			a.setActive(source, ModeSubscription)
+			return nil
+		}
+		log.Warn().Err(err).Str("transport", a.transport).Msg("subscriptions not available, polling the node")
+	}
+
+	source := NewPollingSource(a.rt, a.interval)
+	if err := source.SubscribeChainHead(ch); err != nil {
+		return err
+	}
+	a.setActive(source, ModePolling)
+	return nil
+}
+
+func (a *AutoSource) subscriptionSource() (HeadSource, error) {
+	if a.transport == TransportWebSocket {
+		return client.NewQuorumClient(a.url)
+	}
+	return NewSubscriptionSource(a.rt.RpcClient()), nil
+}
+
+func (a *AutoSource) setActive(source HeadSource, mode string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.active = source
+	a.mode = mode
+}
+
+// Transport is the transport inferred from the url
+func (a *AutoSource) Transport() string {
+	return a.transport
+}
+
+// Mode is the way the blocks are received, empty before subscribing
+func (a *AutoSource) Mode() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.mode
+}
+
+// String describes the active mode, for example "subscription (ipc)"
+func (a *AutoSource) String() string {
+	mode := a.Mode()
+	if len(mode) == 0 {
+		return "not subscribed (" + a.transport + ")"
+	}
+	if mode == ModePolling {
+		return fmt.Sprintf("%v every %v (%v)", mode, a.interval, a.transport)
+	}
+	return mode + " (" + a.transport + ")"
+}
+
+// Connected reports whether the active source is receiving data from the node
+func (a *AutoSource) Connected() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.active != nil && a.active.Connected()
+}
+
+// Stop ends the active source
+func (a *AutoSource) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.active != nil {
+		a.active.Stop()
+	}
+}
+
+// The delay before subscribing again after the subscription of the RPC client fails
+const resubscribeDelay = 5 * time.Second
+
+// SubscriptionSource is a HeadSource receiving the new blocks via the "newHeads" subscription of
+// the RPC client of the node, which supports it over IPC and WebSockets
+type SubscriptionSource struct {
+	client    *rpc.Client
+	connected int32
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+}
+
+func NewSubscriptionSource(client *rpc.Client) *SubscriptionSource {
+	return &SubscriptionSource{
+		client: client,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// SubscribeChainHead subscribes to the new blocks, subscribing again if the subscription fails
+func (s *SubscriptionSource) SubscribeChainHead(ch chan<- qtypes.RawHeader) error {
+
+	// Fail early if the node does not support subscriptions
+	heads := make(chan qtypes.RawHeader)
+	sub, err := s.client.EthSubscribe(context.Background(), heads, "newHeads")
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt32(&s.connected, 1)
+
+	go func() {
+		for {
+			select {
+
+			case <-s.stopCh:
+				sub.Unsubscribe()
+				return
+
+			case header := <-heads:
+				select {
+				case ch <- header:
+				case <-s.stopCh:
+					sub.Unsubscribe()
+					return
+				}
+
+			case err := <-sub.Err():
+				log.Error().Err(err).Msg("subscription to new blocks")
+				atomic.StoreInt32(&s.connected, 0)
+				sub.Unsubscribe()
+
+				sub = s.resubscribe(heads)
+				if sub == nil {
+					return
+				}
+				atomic.StoreInt32(&s.connected, 1)
+
+			}
+		}
+	}()
+
+	return nil
+}
+
+// resubscribe retries until the subscription succeeds, or returns nil if the source is stopped
+func (s *SubscriptionSource) resubscribe(heads chan qtypes.RawHeader) *rpc.ClientSubscription {
+	ticker := time.NewTicker(resubscribeDelay)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return nil
+		case <-ticker.C:
+			sub, err := s.client.EthSubscribe(context.Background(), heads, "newHeads")
+			if err != nil {
+				log.Error().Err(err).Msg("subscribing to new blocks")
+				continue
+			}
+			return sub
+		}
+	}
+}
+
+// Connected reports whether the subscription is active
+func (s *SubscriptionSource) Connected() bool {
+	return atomic.LoadInt32(&s.connected) == 1
+}
+
+// Stop ends the subscription
+func (s *SubscriptionSource) Stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+}
+
+// PollingSource is a HeadSource which asks the node periodically for its current block,
+// and sends all the blocks since the previous one, so none is skipped
+type PollingSource struct {
+	rt        *RedTNode
+	interval  time.Duration
+	connected int32
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+}
+
+func NewPollingSource(rt *RedTNode, interval time.Duration) *PollingSource {
+	return &PollingSource{
+		rt:       rt,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// SubscribeChainHead sends the current block, and then the new ones as they are detected
+func (p *PollingSource) SubscribeChainHead(ch chan<- qtypes.RawHeader) error {
+
+	// Fail early if the node is not accessible
+	latestNumber, err := p.rt.CurrentBlockNumber()
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt32(&p.connected, 1)
+
+	go func() {
+		select {
+		case ch <- qtypes.RawHeader{Number: qtypes.HexNumber(latestNumber)}:
+		case <-p.stopCh:
+			return
+		}
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+
+			case <-p.stopCh:
+				return
+
+			case <-ticker.C:
+				currentNumber, err := p.rt.CurrentBlockNumber()
+				if err != nil {
+					log.Error().Err(err).Msg("polling current block")
+					atomic.StoreInt32(&p.connected, 0)
+					continue
+				}
+				atomic.StoreInt32(&p.connected, 1)
+
+				// Send all blocks from the latest one until the current one
+				for number := latestNumber + 1; number <= currentNumber; number++ {
+					select {
+					case ch <- qtypes.RawHeader{Number: qtypes.HexNumber(number)}:
+					case <-p.stopCh:
+						return
+					}
+				}
+				if currentNumber > latestNumber {
+					latestNumber = currentNumber
+				}
+
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Connected reports whether the last request to the node succeeded
+func (p *PollingSource) Connected() bool {
+	return atomic.LoadInt32(&p.connected) == 1
+}
+
+// Stop ends the polling
+func (p *PollingSource) Stop() {
+	p.stopOnce.Do(func() { close(p.stopCh) })
+}
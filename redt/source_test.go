@@ -0,0 +1,49 @@
+package redt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport(t *testing.T) {
+	for url, expected := range map[string]string{
+		"ws://127.0.0.1:22001":     TransportWebSocket,
+		"wss://node.example.com":   TransportWebSocket,
+		"http://127.0.0.1:22000":   TransportHTTP,
+		"https://node.example.com": TransportHTTP,
+		"/var/run/geth.ipc":        TransportIPC,
+		"geth.ipc":                 TransportIPC,
+	} {
+		transport, err := Transport(url)
+		assert.NoError(t, err, url)
+		assert.Equal(t, expected, transport, url)
+	}
+
+	for _, url := range []string{"ftp://127.0.0.1", "", "localhost:8545", "node.example.com"} {
+		_, err := Transport(url)
+		assert.Error(t, err, url)
+	}
+}
+
+func TestNewHeadSource(t *testing.T) {
+	rt := &RedTNode{}
+
+	source, err := NewHeadSource("/var/run/geth.ipc", rt, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, TransportIPC, source.Transport())
+	assert.Empty(t, source.Mode())
+	assert.False(t, source.Connected())
+	assert.Equal(t, "not subscribed (ipc)", source.String())
+
+	source.setActive(NewPollingSource(rt, time.Second), ModePolling)
+	assert.Equal(t, "polling every 1s (ipc)", source.String())
+
+	source.setActive(NewSubscriptionSource(nil), ModeSubscription)
+	assert.Equal(t, "subscription (ipc)", source.String())
+	source.Stop()
+
+	_, err = NewHeadSource("ftp://127.0.0.1", rt, time.Second)
+	assert.Error(t, err)
+}
@@ -0,0 +1,300 @@
+package redt
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/rs/zerolog/log"
+)
+
+// The defaults of the stall detector
+const (
+	DefaultBlockPeriod  = 5 * time.Second
+	DefaultStallTimeout = 30 * time.Second
+)
+
+const (
+	// How often the detector checks the time since the last block
+	stallCheckPeriod = time.Second
+
+	// How often the liveness of the validators is refreshed while the chain is stalled
+	livenessRefreshPeriod = 10 * time.Second
+)
+
+// ValidatorLiveness tells whether a validator of the current set is reachable from our node
+type ValidatorLiveness struct {
+	Address   common.Address `json:"address"`
+	Operator  string         `json:"operator"`
+	Connected bool           `json:"connected"` // Our node or one of its peers
+	Self      bool           `json:"self"`      // Our node
+}
+
+// Liveness estimates if the validator set can reach the quorum, counting the validators
+// connected to our node. Validators may be alive but not connected to us, so it is a lower bound.
+type Liveness struct {
+	Validators     []ValidatorLiveness `json:"validators"`
+	Live           int                 `json:"live"`
+	Quorum         int                 `json:"quorum"` // 2F+1 of the 3F+1 validators
+	QuorumPossible bool                `json:"quorumPossible"`
+}
+
+// Liveness checks which validators of the current set are our node or its peers.
+// It requires the admin API of the node.
+func (rt *RedTNode) Liveness() (*Liveness, error) {
+
+	peers, err := rt.Peers()
+	if err != nil {
+		return nil, err
+	}
+	connected := map[enode.ID]bool{}
+	for _, p := range peers {
+		if node, err := enode.ParseV4(p.Enode); err == nil {
+			connected[node.ID()] = true
+		}
+	}
+
+	var self enode.ID
+	if ni, err := rt.NodeInfo(); err == nil {
+		if node, err := enode.ParseV4(ni.Enode); err == nil {
+			self = node.ID()
+		}
+	}
+
+	l := &Liveness{Quorum: rt.QuorumSize()}
+	for _, addr := range rt.Validators() {
+		v := ValidatorLiveness{Address: addr, Operator: rt.OperatorName(addr)}
+		if info := rt.ValidatorInfo(addr); info != nil {
+			if node, err := enode.ParseV4(info.Enode); err == nil {
+				v.Self = node.ID() == self
+				v.Connected = v.Self || connected[node.ID()]
+			}
+		}
+		if v.Connected {
+			l.Live++
+		}
+		l.Validators = append(l.Validators, v)
+	}
+	l.QuorumPossible = l.Live >= l.Quorum
+
+	return l, nil
+}
+
+// StallReport is the state of the chain reported by the stall detector
+type StallReport struct {
+	Stalled       bool      `json:"stalled"`
+	LastBlock     int64     `json:"lastBlock"`
+	LastBlockTime time.Time `json:"lastBlockTime"`
+	Elapsed       int64     `json:"elapsed"` // Seconds since the last block, when the report was made
+
+	// The liveness of the validators while stalled, if the admin API of the node is available
+	Liveness      *Liveness `json:"liveness,omitempty"`
+	LivenessError string    `json:"livenessError,omitempty"`
+}
+
+// String summarizes the report, for example for the alerts
+func (r *StallReport) String() string {
+	if !r.Stalled {
+		return fmt.Sprintf("Chain producing blocks again after block %v", r.LastBlock)
+	}
+
+	s := fmt.Sprintf("Chain stalled for %v after block %v", time.Duration(r.Elapsed)*time.Second, r.LastBlock)
+
+	if r.Liveness == nil {
+		return s + ", liveness of the validators unknown"
+	}
+
+	s += fmt.Sprintf(": %v of %v validators connected, quorum of %v", r.Liveness.Live, len(r.Liveness.Validators), r.Liveness.Quorum)
+	if r.Liveness.QuorumPossible {
+		s += " reachable"
+	} else {
+		s += " NOT reachable"
+	}
+
+	var offline []string
+	for _, v := range r.Liveness.Validators {
+		if !v.Connected {
+			name := v.Operator
+			if len(name) == 0 {
+				name = v.Address.Hex()
+			}
+			offline = append(offline, name)
+		}
+	}
+	if len(offline) > 0 {
+		s += " (not connected: " + strings.Join(offline, ", ") + ")"
+	}
+
+	return s
+}
+
+// StallObserver is implemented by the observers of the node which are also notified of the stalls
+type StallObserver interface {
+	// StallChanged is called when the chain stalls, periodically while it is stalled
+	// with the liveness refreshed, and once when it produces blocks again
+	StallChanged(report *StallReport)
+}
+
+// StallDetector reports the chain as stalled when no block is produced in the expected block period
+// plus the timeout. It implements BlockObserver, and notifies the observers of the node which
+// implement StallObserver.
+type StallDetector struct {
+	period  time.Duration
+	timeout time.Duration
+
+	mu            sync.Mutex
+	rt            *RedTNode
+	lastBlock     int64
+	lastBlockTime time.Time
+	stalled       bool
+	lastReport    time.Time
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStallDetector creates the detector, which must be added as observer of the node
+func NewStallDetector(period time.Duration, timeout time.Duration) *StallDetector {
+	return &StallDetector{
+		period:  period,
+		timeout: timeout,
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// Start starts the periodic checks, from the last block processed by the node
+func (d *StallDetector) Start(rt *RedTNode) {
+	d.mu.Lock()
+	d.rt = rt
+	if number := rt.LastBlockProcessed(); number > 0 {
+		if header, err := rt.HeaderByNumber(number); err == nil {
+			d.lastBlock = number
+			d.lastBlockTime = time.Unix(int64(header.Time), 0)
+		}
+	}
+	d.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(stallCheckPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				if report := d.check(now); report != nil {
+					d.notify(report)
+				}
+			case <-d.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the periodic checks. It can be called several times.
+func (d *StallDetector) Stop() {
+	d.stopOnce.Do(func() { close(d.stopCh) })
+}
+
+// BlockProcessed records the time of the block. The end of the stall is reported in the next check.
+func (d *StallDetector) BlockProcessed(r *BlockReport) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastBlock = r.Number
+	d.lastBlockTime = r.Time
+}
+
+// Status is the current state, without the liveness of the validators
+func (d *StallDetector) Status(now time.Time) *StallReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.report(now)
+}
+
+// report must be called with the lock held
+func (d *StallDetector) report(now time.Time) *StallReport {
+	r := &StallReport{
+		Stalled:       d.stalled,
+		LastBlock:     d.lastBlock,
+		LastBlockTime: d.lastBlockTime,
+	}
+	if !d.lastBlockTime.IsZero() {
+		r.Elapsed = int64(now.Sub(d.lastBlockTime) / time.Second)
+	}
+	return r
+}
+
+// check returns the report to notify, if the state changed or the liveness must be refreshed.
+// The liveness is not included yet, as retrieving it takes time.
+func (d *StallDetector) check(now time.Time) *StallReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.lastBlockTime.IsZero() {
+		return nil
+	}
+
+	stalled := now.Sub(d.lastBlockTime) > d.period+d.timeout
+	switch {
+	case stalled && (!d.stalled || now.Sub(d.lastReport) >= livenessRefreshPeriod):
+	case !stalled && d.stalled:
+	default:
+		return nil
+	}
+
+	d.stalled = stalled
+	d.lastReport = now
+	return d.report(now)
+}
+
+// notify completes the report with the liveness and sends it to the observers of the node
+func (d *StallDetector) notify(report *StallReport) {
+	d.mu.Lock()
+	rt := d.rt
+	d.mu.Unlock()
+
+	if report.Stalled {
+		liveness, err := rt.Liveness()
+		if err != nil {
+			report.LivenessError = err.Error()
+		}
+		report.Liveness = liveness
+		log.Warn().Msg(report.String())
+	} else {
+		log.Info().Msg(report.String())
+	}
+
+	rt.notifyStall(report)
+}
+
+// notifyStall sends the report to the observers which are also stall observers.
+// They are called without holding the lock, as they may take time or add observers.
+func (rt *RedTNode) notifyStall(report *StallReport) {
+	rt.observersLock.Lock()
+	observers := make([]BlockObserver, len(rt.observers))
+	copy(observers, rt.observers)
+	rt.observersLock.Unlock()
+
+	for _, o := range observers {
+		if s, ok := o.(StallObserver); ok {
+			s.StallChanged(report)
+		}
+	}
+}
+
+// stopObservers ends the background tasks of the observers which have them, like the stall detector
+func (rt *RedTNode) stopObservers() {
+	rt.observersLock.Lock()
+	observers := make([]BlockObserver, len(rt.observers))
+	copy(observers, rt.observers)
+	rt.observersLock.Unlock()
+
+	for _, o := range observers {
+		if s, ok := o.(interface{ Stop() }); ok {
+			s.Stop()
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package redt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStallDetector(t *testing.T) {
+	d := NewStallDetector(5*time.Second, 30*time.Second)
+	start := time.Unix(1000, 0)
+
+	// Nothing is reported before the first block
+	assert.Nil(t, d.check(start))
+
+	d.BlockProcessed(&BlockReport{Number: 10, Time: time.Unix(1000, 0)})
+	assert.Nil(t, d.check(start.Add(35*time.Second)))
+
+	// Stalled after the period and the timeout
+	report := d.check(start.Add(36 * time.Second))
+	assert.NotNil(t, report)
+	assert.True(t, report.Stalled)
+	assert.Equal(t, int64(10), report.LastBlock)
+	assert.Equal(t, int64(36), report.Elapsed)
+	assert.Equal(t, "Chain stalled for 36s after block 10, liveness of the validators unknown", report.String())
+
+	// Reported again only to refresh the liveness
+	assert.Nil(t, d.check(start.Add(40*time.Second)))
+	report = d.check(start.Add(46 * time.Second))
+	assert.NotNil(t, report)
+	assert.Equal(t, int64(46), report.Elapsed)
+	assert.True(t, d.Status(start.Add(47*time.Second)).Stalled)
+
+	// A new block ends the stall
+	d.BlockProcessed(&BlockReport{Number: 11, Time: time.Unix(1050, 0)})
+	report = d.check(start.Add(51 * time.Second))
+	assert.NotNil(t, report)
+	assert.False(t, report.Stalled)
+	assert.Equal(t, int64(11), report.LastBlock)
+	assert.Nil(t, d.check(start.Add(52*time.Second)))
+}
+
+// stallRecorder adds another observer when it is notified, which requires the lock of the observers
+type stallRecorder struct {
+	rt      *RedTNode
+	reports []*StallReport
+}
+
+func (s *stallRecorder) Start(rt *RedTNode)            { s.rt = rt }
+func (s *stallRecorder) BlockProcessed(r *BlockReport) {}
+func (s *stallRecorder) StallChanged(report *StallReport) {
+	s.reports = append(s.reports, report)
+	s.rt.AddObserver(&stallRecorder{})
+}
+
+func TestNotifyStall(t *testing.T) {
+	rt := &RedTNode{}
+	d := NewStallDetector(5*time.Second, 30*time.Second)
+	rt.AddObserver(d)
+	recorder := &stallRecorder{}
+	rt.AddObserver(recorder)
+
+	// The observers are notified without holding the lock
+	report := &StallReport{Stalled: true, LastBlock: 10}
+	rt.notifyStall(report)
+	assert.Equal(t, []*StallReport{report}, recorder.reports)
+	assert.Len(t, rt.observers, 3)
+
+	// The periodic checks end, even if stopped several times
+	rt.stopObservers()
+	d.Stop()
+	_, open := <-d.stopCh
+	assert.False(t, open)
+}
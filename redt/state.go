@@ -0,0 +1,306 @@
+package redt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+// The version of the format of the state file
+const stateVersion = 1
+
+// How often the counters are saved to the state file
+const stateSavePeriod = time.Minute
+
+// State is the snapshot of the counters saved in the state file, so they survive restarts.
+// The counters include exactly the blocks up to LastBlock, so the ones after it are processed when restored.
+type State struct {
+	Version       int                               `json:"version"`
+	Genesis       common.Hash                       `json:"genesis"` // Identifies the network
+	LastBlock     int64                             `json:"lastBlock"`
+	LastBlockTime uint64                            `json:"lastBlockTime"`
+	LastAuthor    common.Address                    `json:"lastAuthor"`
+	Counters      map[common.Address]*StateCounters `json:"counters"`
+	SavedAt       time.Time                         `json:"savedAt"`
+}
+
+// StateCounters are the counters of a validator in the state file
+type StateCounters struct {
+	Proposals   int `json:"proposals"`
+	Seals       int `json:"seals"`
+	MissedSeals int `json:"missedSeals"`
+	MissedTurns int `json:"missedTurns"`
+}
+
+// LoadState reads the state file. The error satisfies os.IsNotExist if the file does not exist.
+func LoadState(path string) (*State, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &State{}
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %v: %w", path, err)
+	}
+	if state.Version != stateVersion {
+		return nil, fmt.Errorf("%v: unsupported version %v", path, state.Version)
+	}
+
+	return state, nil
+}
+
+// SaveState writes the state file, replacing it atomically so a crash does not leave it corrupted
+func SaveState(path string, state *State) error {
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// genesisHash identifies the network of the node
+func (rt *RedTNode) genesisHash() (common.Hash, error) {
+	header, err := rt.HeaderByNumber(0)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.Hash(), nil
+}
+
+// Snapshot returns the state of the counters, consistent with the last block processed
+func (rt *RedTNode) Snapshot() (*State, error) {
+
+	genesis, err := rt.genesisHash()
+	if err != nil {
+		return nil, err
+	}
+
+	rt.countersLock.RLock()
+	defer rt.countersLock.RUnlock()
+
+	state := rt.snapshot()
+	state.Genesis = genesis
+	return state, nil
+}
+
+// snapshot must be called with the counters lock held
+func (rt *RedTNode) snapshot() *State {
+	state := &State{
+		Version:       stateVersion,
+		LastBlock:     rt.lastBlockProcessed,
+		LastBlockTime: rt.lastBlockTime,
+		LastAuthor:    rt.lastAuthor,
+		Counters:      map[common.Address]*StateCounters{},
+		SavedAt:       time.Now().UTC(),
+	}
+
+	// The validators which left the set keep their counters, in case they are added again
+	counter := func(addr common.Address) *StateCounters {
+		c := state.Counters[addr]
+		if c == nil {
+			c = &StateCounters{}
+			state.Counters[addr] = c
+		}
+		return c
+	}
+	for addr, n := range rt.asProposer {
+		counter(addr).Proposals = n
+	}
+	for addr, n := range rt.asSigner {
+		counter(addr).Seals = n
+	}
+	for addr, n := range rt.missedSeals {
+		counter(addr).MissedSeals = n
+	}
+	for addr, n := range rt.missedTurns {
+		counter(addr).MissedTurns = n
+	}
+
+	return state
+}
+
+// Restore replaces the counters with the ones of the state, which must be of the same network
+// and not ahead of the node. The rolling windows start empty.
+func (rt *RedTNode) Restore(state *State) error {
+
+	genesis, err := rt.genesisHash()
+	if err != nil {
+		return err
+	}
+	if state.Genesis != genesis {
+		return fmt.Errorf("the state is of another network, with genesis %v", state.Genesis.Hex())
+	}
+
+	current, err := rt.CurrentBlockNumber()
+	if err != nil {
+		return err
+	}
+	if state.LastBlock > current {
+		return fmt.Errorf("the state is at block %v, ahead of the node at block %v", state.LastBlock, current)
+	}
+
+	rt.countersLock.Lock()
+	defer rt.countersLock.Unlock()
+
+	rt.restore(state)
+	return nil
+}
+
+// restore must be called with the counters lock held
+func (rt *RedTNode) restore(state *State) {
+	rt.asProposer = map[common.Address]int{}
+	rt.asSigner = map[common.Address]int{}
+	rt.missedSeals = map[common.Address]int{}
+	rt.missedTurns = map[common.Address]int{}
+
+	for _, addr := range rt.valSet {
+		rt.asProposer[addr] = 0
+		rt.asSigner[addr] = 0
+		rt.missedSeals[addr] = 0
+		rt.missedTurns[addr] = 0
+	}
+	for addr, c := range state.Counters {
+		rt.asProposer[addr] = c.Proposals
+		rt.asSigner[addr] = c.Seals
+		rt.missedSeals[addr] = c.MissedSeals
+		rt.missedTurns[addr] = c.MissedTurns
+	}
+
+	rt.lastBlockProcessed = state.LastBlock
+	rt.lastBlockTime = state.LastBlockTime
+	rt.lastAuthor = state.LastAuthor
+	rt.resetWindows()
+}
+
+// SaveState writes the current counters to the state file
+func (rt *RedTNode) SaveState(path string) error {
+	state, err := rt.Snapshot()
+	if err != nil {
+		return err
+	}
+	return SaveState(path, state)
+}
+
+// StartStats initializes the statistics. With a state file, the counters are restored from it and
+// the blocks produced since it was saved are processed, and the file is saved periodically from now on.
+// Without state file, or if it does not exist or can not be used, the statistics start with the
+// given number of blocks in the past. If the blocks can not be processed, the error is returned
+// and the state file is not saved.
+func (rt *RedTNode) StartStats(numBlocks int64, stateFile string) error {
+
+	if len(stateFile) == 0 {
+		return rt.InitializeStats(numBlocks)
+	}
+
+	state, err := LoadState(stateFile)
+	if err == nil {
+		err = rt.Restore(state)
+	}
+
+	switch {
+	case err == nil:
+		log.Info().Str("file", stateFile).Int64("block", state.LastBlock).Msg("counters restored, processing the blocks since then")
+		err = rt.CatchUp()
+	case os.IsNotExist(err):
+		err = rt.InitializeStats(numBlocks)
+	default:
+		log.Error().Err(err).Str("file", stateFile).Msg("ignoring the state file")
+		err = rt.InitializeStats(numBlocks)
+	}
+	if err != nil {
+		return err
+	}
+
+	rt.saver = startStateSaver(stateSavePeriod, func() {
+		if err := rt.SaveState(stateFile); err != nil {
+			log.Error().Err(err).Str("file", stateFile).Msg("saving the state")
+		}
+	})
+	return nil
+}
+
+// StopStats stops saving the state file periodically, and saves it a last time.
+// It does nothing if the statistics were started without state file.
+func (rt *RedTNode) StopStats() {
+	if rt.saver != nil {
+		rt.saver.Stop()
+	}
+}
+
+// stateSaver calls the save function periodically until it is stopped, and then a last time
+type stateSaver struct {
+	stopCh   chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func startStateSaver(period time.Duration, save func()) *stateSaver {
+	s := &stateSaver{
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				save()
+			case <-s.stopCh:
+				save()
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// Stop waits for the last save to finish. It can be called several times.
+func (s *stateSaver) Stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	<-s.done
+}
+
+// CatchUp processes the blocks from the last one processed until the current one.
+// If a block fails, the statistics stop at the previous one and the error is returned.
+func (rt *RedTNode) CatchUp() error {
+
+	current, err := rt.CurrentBlockNumber()
+	if err != nil {
+		return err
+	}
+
+	return rt.processBlocksUntil(rt.LastBlockProcessed()+1, current, rt.includeHeader)
+}
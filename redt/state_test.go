@@ -0,0 +1,78 @@
+package redt
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	_, err := LoadState(path)
+	assert.True(t, os.IsNotExist(err))
+
+	a := common.HexToAddress("0x01")
+	b := common.HexToAddress("0x02")
+
+	rt := &RedTNode{
+		valSet:             []common.Address{a},
+		asProposer:         map[common.Address]int{a: 5, b: 2},
+		asSigner:           map[common.Address]int{a: 10, b: 4},
+		missedSeals:        map[common.Address]int{a: 1},
+		missedTurns:        map[common.Address]int{b: 3},
+		lastAuthor:         a,
+		lastBlockTime:      1000,
+		lastBlockProcessed: 42,
+	}
+	state := rt.snapshot()
+	state.Genesis = common.HexToHash("0xabcd")
+	assert.NoError(t, SaveState(path, state))
+
+	loaded, err := LoadState(path)
+	assert.NoError(t, err)
+	assert.Equal(t, state.Genesis, loaded.Genesis)
+	assert.Equal(t, int64(42), loaded.LastBlock)
+
+	// The validators out of the current set keep their counters
+	restored := &RedTNode{valSet: []common.Address{a, common.HexToAddress("0x03")}}
+	restored.restore(loaded)
+	assert.Equal(t, int64(42), restored.lastBlockProcessed)
+	assert.Equal(t, uint64(1000), restored.lastBlockTime)
+	assert.Equal(t, a, restored.lastAuthor)
+	assert.Equal(t, []ValidatorStats{
+		{Address: a, Proposals: 5, Seals: 10, MissedSeals: 1},
+		{Address: common.HexToAddress("0x03")},
+	}, restored.Stats())
+	assert.Equal(t, 3, restored.missedTurns[b])
+
+	// Only the current version is accepted
+	loaded.Version = 99
+	assert.NoError(t, SaveState(path, loaded))
+	_, err = LoadState(path)
+	assert.Error(t, err)
+
+	// No temporary files are left
+	files, _ := os.ReadDir(filepath.Dir(path))
+	assert.Len(t, files, 1)
+}
+
+func TestStateSaver(t *testing.T) {
+	var saves int32
+	saver := startStateSaver(time.Hour, func() { atomic.AddInt32(&saves, 1) })
+
+	// Stopping saves a last time, only once
+	saver.Stop()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&saves))
+	saver.Stop()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&saves))
+
+	// Without state file there is nothing to stop
+	rt := &RedTNode{}
+	rt.StopStats()
+}
@@ -0,0 +1,240 @@
+package redt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Window is a sliding window of recent blocks, defined by a number of blocks or by a duration.
+// The counters of the windows show the current behaviour of the validators, which the cumulative
+// counters hide after some time running.
+type Window struct {
+	Name     string
+	Blocks   int           // The number of most recent blocks, if not zero
+	Duration time.Duration // The blocks with a timestamp in this period before the most recent one, if not zero
+}
+
+// defaultWindows are the windows of the nodes, unless they are configured with SetWindows
+var defaultWindows = []Window{
+	{Name: "100 blocks", Blocks: 100},
+	{Name: "1h", Duration: time.Hour},
+}
+
+// ParseWindows parses a comma-separated list of windows: a number of blocks like "100",
+// or a duration like "1h" or "30m"
+func ParseWindows(spec string) ([]Window, error) {
+	var windows []Window
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		if blocks, err := strconv.Atoi(item); err == nil {
+			if blocks <= 0 {
+				return nil, fmt.Errorf("invalid window '%v': the number of blocks must be positive", item)
+			}
+			windows = append(windows, Window{Name: item + " blocks", Blocks: blocks})
+			continue
+		}
+
+		duration, err := time.ParseDuration(item)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid window '%v': it must be a number of blocks or a duration", item)
+		}
+		windows = append(windows, Window{Name: item, Duration: duration})
+	}
+
+	return windows, nil
+}
+
+// WindowStats are the counters of a validator in a window
+type WindowStats struct {
+	Window      string `json:"window"`
+	Blocks      int    `json:"blocks"` // The number of blocks currently in the window
+	Proposals   int    `json:"proposals"`
+	Seals       int    `json:"seals"`
+	MissedSeals int    `json:"missedSeals"`
+	MissedTurns int    `json:"missedTurns"`
+}
+
+// windowBlock is what a block adds to the counters of a window
+type windowBlock struct {
+	time       uint64
+	author     common.Address
+	missedTurn common.Address // Zero if the expected proposer is unknown or proposed the block
+	signers    []common.Address
+	missing    []common.Address
+}
+
+// windowCounters are the counters of a validator in a window
+type windowCounters struct {
+	proposals, seals, missedSeals, missedTurns int
+}
+
+// blockRing is a ring buffer with the blocks of a window, oldest first.
+// It grows when full, which only happens for the windows defined by a duration.
+type blockRing struct {
+	items []*windowBlock
+	head  int
+	size  int
+}
+
+func (r *blockRing) push(b *windowBlock) {
+	if r.size == len(r.items) {
+		items := make([]*windowBlock, maxInt(2*len(r.items), 16))
+		for i := 0; i < r.size; i++ {
+			items[i] = r.items[(r.head+i)%len(r.items)]
+		}
+		r.items = items
+		r.head = 0
+	}
+	r.items[(r.head+r.size)%len(r.items)] = b
+	r.size++
+}
+
+func (r *blockRing) oldest() *windowBlock {
+	if r.size == 0 {
+		return nil
+	}
+	return r.items[r.head]
+}
+
+func (r *blockRing) pop() *windowBlock {
+	b := r.oldest()
+	if b != nil {
+		r.items[r.head] = nil
+		r.head = (r.head + 1) % len(r.items)
+		r.size--
+	}
+	return b
+}
+
+// windowState is a window with its blocks and the counters of the validators in them
+type windowState struct {
+	Window
+	blocks   blockRing
+	counters map[common.Address]*windowCounters
+}
+
+func newWindowState(w Window) *windowState {
+	s := &windowState{
+		Window:   w,
+		counters: map[common.Address]*windowCounters{},
+	}
+	if w.Blocks > 0 {
+		s.blocks.items = make([]*windowBlock, w.Blocks)
+	}
+	return s
+}
+
+func (s *windowState) counter(addr common.Address) *windowCounters {
+	c := s.counters[addr]
+	if c == nil {
+		c = &windowCounters{}
+		s.counters[addr] = c
+	}
+	return c
+}
+
+// add includes the block in the window, removing the ones which are out of it now
+func (s *windowState) add(b *windowBlock) {
+	if s.Blocks > 0 && s.blocks.size == s.Blocks {
+		s.apply(s.blocks.pop(), -1)
+	}
+
+	s.blocks.push(b)
+	s.apply(b, 1)
+
+	if s.Duration > 0 {
+		seconds := uint64(s.Duration / time.Second)
+		for oldest := s.blocks.oldest(); oldest != b && oldest.time+seconds <= b.time; oldest = s.blocks.oldest() {
+			s.apply(s.blocks.pop(), -1)
+		}
+	}
+}
+
+// apply adds (delta 1) or removes (delta -1) a block from the counters
+func (s *windowState) apply(b *windowBlock, delta int) {
+	s.counter(b.author).proposals += delta
+	for _, addr := range b.signers {
+		s.counter(addr).seals += delta
+	}
+	for _, addr := range b.missing {
+		s.counter(addr).missedSeals += delta
+	}
+	if b.missedTurn != (common.Address{}) {
+		s.counter(b.missedTurn).missedTurns += delta
+	}
+}
+
+// stats returns the counters of the validator in the window
+func (s *windowState) stats(addr common.Address) WindowStats {
+	st := WindowStats{Window: s.Name, Blocks: s.blocks.size}
+	if c := s.counters[addr]; c != nil {
+		st.Proposals = c.proposals
+		st.Seals = c.seals
+		st.MissedSeals = c.missedSeals
+		st.MissedTurns = c.missedTurns
+	}
+	return st
+}
+
+// SetWindows replaces the windows of the node, which start empty
+func (rt *RedTNode) SetWindows(windows []Window) {
+	rt.countersLock.Lock()
+	defer rt.countersLock.Unlock()
+
+	rt.windows = make([]*windowState, len(windows))
+	for i, w := range windows {
+		rt.windows[i] = newWindowState(w)
+	}
+}
+
+// resetWindows empties the windows. It must be called with the counters lock held.
+func (rt *RedTNode) resetWindows() {
+	for i, w := range rt.windows {
+		rt.windows[i] = newWindowState(w.Window)
+	}
+}
+
+// updateWindows includes the block in all windows. It must be called with the counters lock held.
+func (rt *RedTNode) updateWindows(r *BlockReport) {
+	b := &windowBlock{
+		time:    r.Timestamp,
+		author:  r.Proposer.Address,
+		signers: Addresses(r.Signers),
+		missing: Addresses(r.Missing),
+	}
+	if r.MissedTurn {
+		b.missedTurn = r.ExpectedProposer.Address
+	}
+
+	for _, w := range rt.windows {
+		w.add(b)
+	}
+}
+
+// windowStats returns the counters of the validator in all windows. It must be called with the counters lock held.
+func (rt *RedTNode) windowStats(addr common.Address) []WindowStats {
+	if len(rt.windows) == 0 {
+		return nil
+	}
+	stats := make([]WindowStats, len(rt.windows))
+	for i, w := range rt.windows {
+		stats[i] = w.stats(addr)
+	}
+	return stats
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
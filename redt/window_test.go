@@ -0,0 +1,85 @@
+package redt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWindows(t *testing.T) {
+	windows, err := ParseWindows("100, 1h,30m")
+	assert.NoError(t, err)
+	assert.Equal(t, []Window{
+		{Name: "100 blocks", Blocks: 100},
+		{Name: "1h", Duration: time.Hour},
+		{Name: "30m", Duration: 30 * time.Minute},
+	}, windows)
+
+	windows, err = ParseWindows("")
+	assert.NoError(t, err)
+	assert.Empty(t, windows)
+
+	for _, spec := range []string{"0", "-5", "1x", "-1h"} {
+		_, err := ParseWindows(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	a := common.HexToAddress("0x01")
+	b := common.HexToAddress("0x02")
+
+	rt := &RedTNode{}
+	rt.SetWindows([]Window{
+		{Name: "3 blocks", Blocks: 3},
+		{Name: "20s", Duration: 20 * time.Second},
+	})
+
+	// a proposes and b does not sign, then b proposes and both sign, every 5 seconds
+	for i := 0; i < 40; i++ {
+		r := &BlockReport{Number: int64(i), Timestamp: uint64(1000 + 5*i), Proposer: Validator{Address: a}, Signers: []Validator{{Address: a}}, Missing: []Validator{{Address: b}}}
+		if i%2 == 1 {
+			r.Proposer = Validator{Address: b}
+			r.Signers = []Validator{{Address: a}, {Address: b}}
+			r.Missing = nil
+		}
+		if i > 0 {
+			r.setExpectedProposer(Validator{Address: b})
+		}
+		rt.updateWindows(r)
+	}
+
+	// The last 3 blocks are 37 (b), 38 (a) and 39 (b)
+	assert.Equal(t, WindowStats{Window: "3 blocks", Blocks: 3, Proposals: 1, Seals: 3, MissedTurns: 0}, rt.windowStats(a)[0])
+	assert.Equal(t, WindowStats{Window: "3 blocks", Blocks: 3, Proposals: 2, Seals: 2, MissedSeals: 1, MissedTurns: 1}, rt.windowStats(b)[0])
+
+	// The blocks in the last 20 seconds are 36 to 39
+	assert.Equal(t, WindowStats{Window: "20s", Blocks: 4, Proposals: 2, Seals: 4}, rt.windowStats(a)[1])
+	assert.Equal(t, WindowStats{Window: "20s", Blocks: 4, Proposals: 2, Seals: 2, MissedSeals: 2, MissedTurns: 2}, rt.windowStats(b)[1])
+
+	// An unknown validator has no activity in the windows
+	assert.Equal(t, WindowStats{Window: "3 blocks", Blocks: 3}, rt.windowStats(common.HexToAddress("0x03"))[0])
+
+	rt.resetWindows()
+	assert.Equal(t, WindowStats{Window: "20s", Blocks: 0}, rt.windowStats(a)[1])
+}
+
+func TestBlockRing(t *testing.T) {
+	var r blockRing
+	assert.Nil(t, r.pop())
+
+	blocks := make([]*windowBlock, 40)
+	for i := range blocks {
+		blocks[i] = &windowBlock{time: uint64(i)}
+		r.push(blocks[i])
+		if i%3 == 0 {
+			assert.Same(t, blocks[i/3], r.pop())
+		}
+	}
+	for i := 14; i < 40; i++ {
+		assert.Same(t, blocks[i], r.pop())
+	}
+	assert.Equal(t, 0, r.size)
+}
@@ -0,0 +1,34 @@
+package serve
+
+import (
+	"net/http"
+
+	"github.com/hesusruiz/signers/alerts"
+	"github.com/labstack/echo/v4"
+)
+
+// apiAlerts are the alerts firing, if the alerting rules are configured for the network
+type apiAlerts struct {
+	Enabled bool           `json:"enabled"`
+	Alerts  []alerts.Alert `json:"alerts"`
+}
+
+// registerAlerts adds the alerts page and its JSON endpoint
+func (s *Server) registerAlerts(pages *echo.Group, api *echo.Group) {
+	viewer := s.auth.require(RoleViewer)
+
+	pages.GET("/alerts", func(c echo.Context) error {
+		return s.renderPage(c, "alerts.html")
+	}, viewer)
+
+	api.GET("/alerts", s.apiAlerts, viewer)
+}
+
+func (s *Server) apiAlerts(c echo.Context) error {
+	report := apiAlerts{Alerts: []alerts.Alert{}}
+	if s.engine != nil {
+		report.Enabled = true
+		report.Alerts = s.engine.Active()
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,157 @@
+package serve
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/labstack/echo/v4"
+)
+
+// apiValidator identifies a validator in the responses of the API
+type apiValidator struct {
+	Address  common.Address `json:"address"`
+	Operator string         `json:"operator"`
+}
+
+// apiValidatorInfo is a validator in the current set with the counters since the server started
+type apiValidatorInfo struct {
+	redt.ValidatorStats
+	Enode string `json:"enode"`
+}
+
+// apiLiveStats are the counters of a validator since the server started, up to block To
+type apiLiveStats struct {
+	redt.ValidatorStats
+	To int64 `json:"to"`
+}
+
+// registerAPI adds the routes of the JSON API, version 1
+func (s *Server) registerAPI(api *echo.Group) {
+	viewer := s.auth.require(RoleViewer)
+
+	api.GET("/blocks/latest", s.apiLatestBlock, viewer)
+	api.GET("/blocks/:number", s.apiBlockByNumber, viewer)
+	api.GET("/validators", s.apiListValidators, viewer)
+	api.GET("/validators/:address/stats", s.apiValidatorStats, viewer)
+}
+
+func (s *Server) apiLatestBlock(c echo.Context) error {
+
+	// Use the last block processed by the pipeline, or ask the node if we did not receive any yet
+	number := atomic.LoadInt64(&s.latestNumber)
+	if number == 0 {
+		number = -1
+	}
+
+	block, err := s.blockForNumber(number)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+	return c.JSON(http.StatusOK, block)
+}
+
+func (s *Server) apiBlockByNumber(c echo.Context) error {
+
+	number, err := strconv.ParseInt(c.Param("number"), 0, 64)
+	if err != nil || number < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid block number")
+	}
+
+	// Only the blocks which do not exist yet are not found, the rest are errors of the node
+	block, err := s.blockForNumber(number)
+	if errors.Is(err, ethereum.NotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "block not found")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+	return c.JSON(http.StatusOK, block)
+}
+
+func (s *Server) apiListValidators(c echo.Context) error {
+
+	stats := s.rt.Stats()
+
+	validators := make([]apiValidatorInfo, len(stats))
+	for i, st := range stats {
+		validators[i].ValidatorStats = st
+		if info := s.rt.ValidatorInfo(st.Address); info != nil {
+			validators[i].Enode = info.Enode
+		}
+	}
+
+	return c.JSON(http.StatusOK, validators)
+}
+
+// apiValidatorStats returns the counters of a validator. Without a range they are the live counters
+// since the server started. With a range, they are calculated from the history database.
+func (s *Server) apiValidatorStats(c echo.Context) error {
+
+	if !common.IsHexAddress(c.Param("address")) {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid address")
+	}
+	address := common.HexToAddress(c.Param("address"))
+
+	fromStr := c.QueryParam("from")
+	toStr := c.QueryParam("to")
+
+	// The live counters
+	if fromStr == "" && toStr == "" {
+		for _, st := range s.rt.Stats() {
+			if st.Address == address {
+				return c.JSON(http.StatusOK, apiLiveStats{
+					ValidatorStats: st,
+					To:             s.rt.LastBlockProcessed(),
+				})
+			}
+		}
+		return echo.NewHTTPError(http.StatusNotFound, "address is not in the validator set")
+	}
+
+	// A range requires the database
+	if s.db == nil {
+		return echo.NewHTTPError(http.StatusNotImplemented, "history database not configured")
+	}
+
+	from, err := strconv.ParseInt(fromStr, 0, 64)
+	if err != nil || from < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid 'from' block number")
+	}
+
+	// The end of the range defaults to the last block processed
+	to := s.rt.LastBlockProcessed()
+	if toStr != "" {
+		to, err = strconv.ParseInt(toStr, 0, 64)
+		if err != nil || to < from {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid 'to' block number")
+		}
+	}
+
+	stats, err := s.db.SignerStatsForRange(address.String(), from, to)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// blockForNumber retrieves the block from the node and builds its report
+func (s *Server) blockForNumber(number int64) (*redt.BlockReport, error) {
+
+	_, report, err := s.rt.ReportForBlockNumber(number)
+	return report, err
+}
+
+// apiValidators adds the operator names to a list of addresses
+func (s *Server) apiValidators(addresses []common.Address) []apiValidator {
+	vals := make([]apiValidator, len(addresses))
+	for i, addr := range addresses {
+		vals[i] = apiValidator{Address: addr, Operator: s.rt.OperatorName(addr)}
+	}
+	return vals
+}
@@ -0,0 +1,85 @@
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// rpcError is an error of the node with its JSON-RPC code
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e rpcError) Error() string  { return e.message }
+func (e rpcError) ErrorCode() int { return e.code }
+
+// fakeNode answers the JSON-RPC calls with the results of the function, or with its error
+func fakeNode(t *testing.T, call func(method string, params []json.RawMessage) (any, error)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		result, err := call(req.Method, req.Params)
+		if err != nil {
+			code := -32000
+			var rpcErr rpc.Error
+			if errors.As(err, &rpcErr) {
+				code = rpcErr.ErrorCode()
+			}
+			resp["error"] = map[string]any{"code": code, "message": err.Error()}
+		} else {
+			resp["result"] = result
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestAPIBlockByNumber(t *testing.T) {
+
+	// The node does not have block 100 yet, and fails with the rest
+	node := fakeNode(t, func(method string, params []json.RawMessage) (any, error) {
+		switch method {
+		case "istanbul_getValidators":
+			return []string{}, nil
+		case "eth_getBlockByNumber":
+			if string(params[0]) == `"0x64"` {
+				return nil, nil
+			}
+		}
+		return nil, errors.New("internal error")
+	})
+	defer node.Close()
+
+	rt, err := redt.NewRedTNodeWithRegistry(node.URL, nil)
+	assert.NoError(t, err)
+	s := &Server{rt: rt}
+
+	e := echo.New()
+	s.registerAPI(e.Group("/api/v1"))
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/blocks/abc"))
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/blocks/-1"))
+	assert.Equal(t, http.StatusNotFound, get("/api/v1/blocks/100"))
+	assert.Equal(t, http.StatusBadGateway, get("/api/v1/blocks/99"))
+	assert.Equal(t, http.StatusBadGateway, get("/api/v1/blocks/latest"))
+}
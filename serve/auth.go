@@ -0,0 +1,335 @@
+package serve
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// The roles of the users of the web server. Each role can access everything the previous ones can.
+const (
+	// RoleNone can only access the health checks
+	RoleNone = "none"
+
+	// RoleViewer can access the public read-only views: the live table, history, validators and blocks
+	RoleViewer = "viewer"
+
+	// RoleOperator can also access the details of the infrastructure: the peers of the nodes and the metrics
+	RoleOperator = "operator"
+
+	// RoleAdmin can also perform administrative actions
+	RoleAdmin = "admin"
+)
+
+var roleLevels = map[string]int{
+	RoleNone:     0,
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// hasRole is true if the role includes the permissions of the required one
+func hasRole(role string, required string) bool {
+	return roleLevels[role] >= roleLevels[required]
+}
+
+// AuthConfig enables the authentication of the requests to the web server.
+// When it is not configured, everybody has the admin role.
+//
+//	auth:
+//	  anonymous: viewer
+//	  tokens:
+//	    - name: prometheus
+//	      token: 6f1ed002ab5595859014ebf0951522d9
+//	      role: operator
+//	  users:
+//	    - name: admin
+//	      password: $2a$10$qvK/fWWtvbvFXrhNDD.2cuCq3jGVSKNXNy3wSZ8k3u6rZXh4Ln5X2
+//	      role: admin
+//	  oidc:
+//	    issuer: https://accounts.example.com/realms/alastria
+//	    audience: signers
+type AuthConfig struct {
+	// The role of the requests without credentials, "viewer" by default.
+	// Use "none" to require authentication for everything except the health checks.
+	Anonymous string `yaml:"anonymous"`
+
+	// Static tokens, sent in the header "Authorization: Bearer <token>"
+	Tokens []TokenConfig `yaml:"tokens"`
+
+	// Users with HTTP basic authentication
+	Users []UserConfig `yaml:"users"`
+
+	// Tokens issued by an OpenID Connect provider, sent as bearer tokens
+	OIDC *OIDCConfig `yaml:"oidc"`
+}
+
+type TokenConfig struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+	Role  string `yaml:"role"`
+}
+
+type UserConfig struct {
+	Name string `yaml:"name"`
+
+	// The bcrypt hash of the password, for example from 'htpasswd -nbB user password'
+	Password string `yaml:"password"`
+
+	Role string `yaml:"role"`
+}
+
+// LoadAuthConfig reads the authentication configuration from its own file
+func LoadAuthConfig(path string) (*AuthConfig, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &AuthConfig{}
+	err = yaml.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %v: %w", path, err)
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the configuration and applies the defaults
+func (cfg *AuthConfig) Validate() error {
+
+	if len(cfg.Anonymous) == 0 {
+		cfg.Anonymous = RoleViewer
+	}
+	if _, ok := roleLevels[cfg.Anonymous]; !ok {
+		return fmt.Errorf("auth: unknown anonymous role '%v'", cfg.Anonymous)
+	}
+
+	for _, t := range cfg.Tokens {
+		if len(t.Token) < minTokenLength {
+			return fmt.Errorf("auth: token %v: shorter than %v characters", t.Name, minTokenLength)
+		}
+		if _, ok := roleLevels[t.Role]; !ok {
+			return fmt.Errorf("auth: token %v: unknown role '%v'", t.Name, t.Role)
+		}
+	}
+
+	for _, u := range cfg.Users {
+		if len(u.Name) == 0 {
+			return fmt.Errorf("auth: user without name")
+		}
+		if _, err := bcrypt.Cost([]byte(u.Password)); err != nil {
+			return fmt.Errorf("auth: user %v: the password is not a bcrypt hash", u.Name)
+		}
+		if _, ok := roleLevels[u.Role]; !ok {
+			return fmt.Errorf("auth: user %v: unknown role '%v'", u.Name, u.Role)
+		}
+	}
+
+	if cfg.OIDC != nil {
+		if err := cfg.OIDC.validate(); err != nil {
+			return fmt.Errorf("auth: oidc: %w", err)
+		}
+	}
+
+	return nil
+}
+
+const (
+	minTokenLength = 16
+
+	// The name of the query parameter with the token, for WebSockets and events from browsers
+	accessTokenParam = "access_token"
+
+	// The key in the echo context with the identity of the request
+	identityKey = "identity"
+)
+
+// identity is the user who performed a request
+type identity struct {
+	Name string
+	Role string
+}
+
+// authenticator verifies the credentials of the requests
+type authenticator struct {
+	cfg  *AuthConfig
+	oidc *oidcVerifier
+
+	// The basic credentials already verified, as bcrypt is slow on purpose
+	mu       sync.Mutex
+	verified map[[sha256.Size]byte]*identity
+}
+
+// newAuthenticator returns nil when the configuration is nil, so authentication is disabled
+func newAuthenticator(cfg *AuthConfig) *authenticator {
+	if cfg == nil {
+		return nil
+	}
+
+	a := &authenticator{
+		cfg:      cfg,
+		verified: map[[sha256.Size]byte]*identity{},
+	}
+	if cfg.OIDC != nil {
+		a.oidc = newOIDCVerifier(cfg.OIDC)
+	}
+	return a
+}
+
+// require is the middleware allowing only the requests with at least the given role.
+// Requests without credentials get 401, and requests with a role too low get 403.
+func (a *authenticator) require(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+
+			// Without authentication everything is allowed
+			if a == nil {
+				c.Set(identityKey, &identity{Role: RoleAdmin})
+				return next(c)
+			}
+
+			id, err := a.authenticate(c.Request())
+			if err != nil {
+				return a.unauthorized(c, err.Error())
+			}
+
+			if id == nil {
+				if !hasRole(a.cfg.Anonymous, role) {
+					return a.unauthorized(c, "authentication required")
+				}
+				id = &identity{Role: a.cfg.Anonymous}
+			}
+
+			if !hasRole(id.Role, role) {
+				return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("the %v role is required", role))
+			}
+
+			c.Set(identityKey, id)
+			return next(c)
+		}
+	}
+}
+
+// unauthorized tells the client the authentication methods it can use
+func (a *authenticator) unauthorized(c echo.Context, message string) error {
+	if len(a.cfg.Users) > 0 {
+		c.Response().Header().Add(echo.HeaderWWWAuthenticate, `Basic realm="signers"`)
+	}
+	if len(a.cfg.Tokens) > 0 || a.oidc != nil {
+		c.Response().Header().Add(echo.HeaderWWWAuthenticate, `Bearer realm="signers"`)
+	}
+	return echo.NewHTTPError(http.StatusUnauthorized, message)
+}
+
+// authenticate returns the identity of the credentials of the request, or nil if it has none
+func (a *authenticator) authenticate(r *http.Request) (*identity, error) {
+
+	header := r.Header.Get(echo.HeaderAuthorization)
+
+	// Browsers can not set headers in WebSockets and event streams, so the token can go in the url
+	if len(header) == 0 && isStreaming(r) {
+		if token := r.URL.Query().Get(accessTokenParam); len(token) > 0 {
+			header = "Bearer " + token
+		}
+	}
+
+	if len(header) == 0 {
+		return nil, nil
+	}
+
+	scheme, credentials, found := strings.Cut(header, " ")
+	if !found {
+		return nil, fmt.Errorf("invalid authorization header")
+	}
+
+	switch strings.ToLower(scheme) {
+
+	case "basic":
+		user, password, ok := r.BasicAuth()
+		if !ok {
+			return nil, fmt.Errorf("invalid basic credentials")
+		}
+		return a.authenticateUser(user, password)
+
+	case "bearer":
+		return a.authenticateToken(strings.TrimSpace(credentials))
+
+	default:
+		return nil, fmt.Errorf("unsupported authorization scheme '%v'", scheme)
+
+	}
+}
+
+// authenticateUser checks the password against the bcrypt hash of the user
+func (a *authenticator) authenticateUser(name string, password string) (*identity, error) {
+
+	key := sha256.Sum256([]byte(name + ":" + password))
+	a.mu.Lock()
+	id := a.verified[key]
+	a.mu.Unlock()
+	if id != nil {
+		return id, nil
+	}
+
+	for _, u := range a.cfg.Users {
+		if u.Name != name {
+			continue
+		}
+		if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+			break
+		}
+
+		id = &identity{Name: u.Name, Role: u.Role}
+		a.mu.Lock()
+		a.verified[key] = id
+		a.mu.Unlock()
+		return id, nil
+	}
+
+	return nil, fmt.Errorf("invalid user or password")
+}
+
+// authenticateToken checks the static tokens first, and then the ones of the OIDC provider
+func (a *authenticator) authenticateToken(token string) (*identity, error) {
+
+	for _, t := range a.cfg.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return &identity{Name: t.Name, Role: t.Role}, nil
+		}
+	}
+
+	if a.oidc != nil {
+		return a.oidc.verify(token)
+	}
+
+	return nil, fmt.Errorf("invalid token")
+}
+
+// isStreaming is true for the requests of WebSockets and event streams
+func isStreaming(r *http.Request) bool {
+	return websocket.IsWebSocketUpgrade(r) || strings.Contains(r.Header.Get(echo.HeaderAccept), "text/event-stream")
+}
+
+// roleOf returns the role of the request, set by the middleware
+func roleOf(c echo.Context) string {
+	if id, ok := c.Get(identityKey).(*identity); ok {
+		return id.Role
+	}
+	return RoleNone
+}
@@ -0,0 +1,165 @@
+package serve
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testToken = "0123456789abcdef0123456789abcdef"
+
+func testAuthenticator(t *testing.T, anonymous string) *authenticator {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	cfg := &AuthConfig{
+		Anonymous: anonymous,
+		Tokens:    []TokenConfig{{Name: "prometheus", Token: testToken, Role: RoleOperator}},
+		Users:     []UserConfig{{Name: "admin", Password: string(hash), Role: RoleAdmin}},
+	}
+	assert.NoError(t, cfg.Validate())
+	return newAuthenticator(cfg)
+}
+
+// request performs a request to a route requiring the role, and returns the status and the role seen by the handler
+func request(a *authenticator, role string, prepare func(r *http.Request)) (int, string) {
+	e := echo.New()
+	var seen string
+	e.GET("/", func(c echo.Context) error {
+		seen = roleOf(c)
+		return c.NoContent(http.StatusOK)
+	}, a.require(role))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if prepare != nil {
+		prepare(r)
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	return w.Code, seen
+}
+
+func TestRequireRole(t *testing.T) {
+	a := testAuthenticator(t, "")
+
+	// Without authentication configured everything is allowed
+	status, role := request(nil, RoleAdmin, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, RoleAdmin, role)
+
+	// Anonymous requests are viewers by default
+	status, role = request(a, RoleViewer, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, RoleViewer, role)
+	status, _ = request(a, RoleOperator, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// Static tokens
+	bearer := func(token string) func(r *http.Request) {
+		return func(r *http.Request) { r.Header.Set(echo.HeaderAuthorization, "Bearer "+token) }
+	}
+	status, role = request(a, RoleOperator, bearer(testToken))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, RoleOperator, role)
+	status, _ = request(a, RoleAdmin, bearer(testToken))
+	assert.Equal(t, http.StatusForbidden, status)
+	status, _ = request(a, RoleViewer, bearer("wrong"))
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// Basic authentication
+	basic := func(user, password string) func(r *http.Request) {
+		return func(r *http.Request) { r.SetBasicAuth(user, password) }
+	}
+	status, role = request(a, RoleAdmin, basic("admin", "secret"))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, RoleAdmin, role)
+	status, _ = request(a, RoleAdmin, basic("admin", "secret"))
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = request(a, RoleViewer, basic("admin", "wrong"))
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// The token in the url is only accepted for WebSockets and event streams
+	query := func(streaming bool) func(r *http.Request) {
+		return func(r *http.Request) {
+			r.URL.RawQuery = accessTokenParam + "=" + testToken
+			if streaming {
+				r.Header.Set(echo.HeaderAccept, "text/event-stream")
+			}
+		}
+	}
+	status, _ = request(a, RoleOperator, query(false))
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = request(a, RoleOperator, query(true))
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestWebSocketToken(t *testing.T) {
+	a := testAuthenticator(t, RoleNone)
+
+	e := echo.New()
+	e.GET("/ws", func(c echo.Context) error {
+		ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+		if err != nil {
+			return err
+		}
+		return ws.Close()
+	}, a.require(RoleViewer))
+	e.GET("/static/*", staticHandler(""))
+	server := httptest.NewServer(e)
+	defer server.Close()
+	uri := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	// Without the token the upgrade is rejected
+	_, resp, err := websocket.DefaultDialer.Dial(uri, nil)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// The pages pass the token in the url, as browsers can not set the headers of WebSockets
+	ws, _, err := websocket.DefaultDialer.Dial(uri+"?"+accessTokenParam+"="+testToken, nil)
+	assert.NoError(t, err)
+	if ws != nil {
+		ws.Close()
+	}
+
+	resp, err = http.Get(server.URL + "/static/app.js")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	script, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(script), "new WebSocket(withToken(uri))")
+	assert.Contains(t, string(script), "'"+accessTokenParam+"='")
+}
+
+func TestAnonymousNone(t *testing.T) {
+	a := testAuthenticator(t, RoleNone)
+
+	status, _ := request(a, RoleViewer, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestValidateAuthConfig(t *testing.T) {
+	assert.Error(t, (&AuthConfig{Anonymous: "guest"}).Validate())
+	assert.Error(t, (&AuthConfig{Tokens: []TokenConfig{{Name: "short", Token: "1234", Role: RoleViewer}}}).Validate())
+	assert.Error(t, (&AuthConfig{Tokens: []TokenConfig{{Name: "norole", Token: testToken}}}).Validate())
+	assert.Error(t, (&AuthConfig{Users: []UserConfig{{Name: "plain", Password: "secret", Role: RoleViewer}}}).Validate())
+	assert.Error(t, (&AuthConfig{OIDC: &OIDCConfig{Issuer: "https://issuer.example.com"}}).Validate())
+
+	cfg := &AuthConfig{OIDC: &OIDCConfig{Issuer: "https://issuer.example.com", Audience: "signers"}}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, RoleViewer, cfg.Anonymous)
+	assert.Equal(t, defaultRolesClaim, cfg.OIDC.RolesClaim)
+	assert.Equal(t, RoleViewer, cfg.OIDC.Role)
+}
+
+func TestVisibleEvent(t *testing.T) {
+	assert.True(t, visibleEvent(event{Type: EventBlock}, RoleViewer))
+	assert.False(t, visibleEvent(event{Type: EventPeers}, RoleViewer))
+	assert.True(t, visibleEvent(event{Type: EventPeers}, RoleOperator))
+}
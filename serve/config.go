@@ -0,0 +1,199 @@
+package serve
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/hesusruiz/signers/redt"
+	"gopkg.in/yaml.v3"
+)
+
+// The consensus engines supported. Only IBFT encodes the committed seals in the format we decode.
+const (
+	ConsensusIBFT = "ibft"
+)
+
+// Config is the configuration of the web server, in YAML format when read from a file.
+//
+//	ip: 0.0.0.0
+//	port: 8080
+//	maxBlockAge: 60
+//	networks:
+//	  - name: redt
+//	    urls: [ws://127.0.0.1:22001, http://127.0.0.1:22000]
+//	  - name: testnet
+//	    urls: [http://10.0.0.5:8545]
+//	    registry: testnet-validators.yaml
+//	    rules: testnet-rules.yaml
+//	    windows: 100,1h
+//
+// The first network is also served at the root of the web server, as when monitoring a single network.
+type Config struct {
+	IP   string `yaml:"ip"`
+	Port int64  `yaml:"port"`
+
+	// Maximum seconds without receiving a block before the server is reported as not ready
+	MaxBlockAge int64 `yaml:"maxBlockAge"`
+
+	Networks []NetworkConfig `yaml:"networks"`
+
+	// Directory with templates and static files replacing the embedded ones with the same names (optional)
+	Templates string `yaml:"templates"`
+
+	// Authentication of the requests (optional)
+	Auth *AuthConfig `yaml:"auth"`
+}
+
+// NetworkConfig describes a network to monitor. Each network has its own pipeline and counters.
+type NetworkConfig struct {
+	// The name used in the urls of the network, only letters, digits, '-' and '_'
+	Name string `yaml:"name"`
+
+	// Urls of nodes of the network. The first one which answers is used.
+	URLs []string `yaml:"urls"`
+
+	// File with the operators and enodes of the validators. The default is the RedT registry.
+	Registry string `yaml:"registry"`
+
+	// Only "ibft" is supported, which is the default
+	Consensus string `yaml:"consensus"`
+
+	// Number of blocks in the past to process before starting
+	Blocks int64 `yaml:"blocks"`
+
+	// Polling interval in seconds, when the url is HTTP or IPC
+	Refresh int64 `yaml:"refresh"`
+
+	// The SQLite database with history data (optional). Each block processed is stored in it.
+	DSN string `yaml:"dsn"`
+
+	// File with the alerting rules and notifiers (optional)
+	Rules string `yaml:"rules"`
+
+	// The expected seconds between blocks, and the seconds without a block beyond it before the
+	// chain is reported as stalled. The defaults are 5 and 30.
+	BlockPeriod  int64 `yaml:"blockPeriod"`
+	StallTimeout int64 `yaml:"stallTimeout"`
+
+	// File where the counters are saved periodically, to continue from them after a restart (optional).
+	// Each network needs its own file.
+	State string `yaml:"state"`
+
+	// Rolling windows of recent blocks, by number of blocks or duration, like "100,1h" (optional).
+	// The default are the last 100 blocks and the last hour.
+	Windows string `yaml:"windows"`
+}
+
+const (
+	defaultIP          = "0.0.0.0"
+	defaultPort        = 8080
+	defaultMaxBlockAge = 60
+	defaultRefresh     = 2
+)
+
+var networkNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// LoadConfig reads and validates the configuration file
+func LoadConfig(path string) (*Config, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	err = yaml.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %v: %w", path, err)
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the configuration and applies the defaults
+func (cfg *Config) Validate() error {
+
+	if len(cfg.IP) == 0 {
+		cfg.IP = defaultIP
+	}
+	if cfg.Port == 0 {
+		cfg.Port = defaultPort
+	}
+	if cfg.MaxBlockAge <= 0 {
+		cfg.MaxBlockAge = defaultMaxBlockAge
+	}
+
+	if cfg.Auth != nil {
+		if err := cfg.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if len(cfg.Networks) == 0 {
+		return fmt.Errorf("no networks configured")
+	}
+
+	names := map[string]bool{}
+	states := map[string]bool{}
+	dsns := map[string]bool{}
+
+	for i := range cfg.Networks {
+		n := &cfg.Networks[i]
+
+		if !networkNameRegexp.MatchString(n.Name) {
+			return fmt.Errorf("network %v: invalid name '%v'", i+1, n.Name)
+		}
+		if names[n.Name] {
+			return fmt.Errorf("network %v: duplicate name", n.Name)
+		}
+		names[n.Name] = true
+
+		if len(n.URLs) == 0 {
+			return fmt.Errorf("network %v: no urls", n.Name)
+		}
+
+		if len(n.Consensus) == 0 {
+			n.Consensus = ConsensusIBFT
+		}
+		if n.Consensus != ConsensusIBFT {
+			return fmt.Errorf("network %v: unsupported consensus engine '%v'", n.Name, n.Consensus)
+		}
+
+		if n.Blocks < 0 {
+			return fmt.Errorf("network %v: negative number of blocks", n.Name)
+		}
+		if n.Refresh <= 0 {
+			n.Refresh = defaultRefresh
+		}
+		if len(n.State) > 0 {
+			if states[n.State] {
+				return fmt.Errorf("network %v: state file used by another network", n.Name)
+			}
+			states[n.State] = true
+		}
+		if len(n.DSN) > 0 {
+			if dsns[n.DSN] {
+				return fmt.Errorf("network %v: history database used by another network", n.Name)
+			}
+			dsns[n.DSN] = true
+		}
+		if n.BlockPeriod <= 0 {
+			n.BlockPeriod = int64(redt.DefaultBlockPeriod / time.Second)
+		}
+		if n.StallTimeout <= 0 {
+			n.StallTimeout = int64(redt.DefaultStallTimeout / time.Second)
+		}
+		if _, err := redt.ParseWindows(n.Windows); err != nil {
+			return fmt.Errorf("network %v: %w", n.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package serve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serve.yaml")
+	err := os.WriteFile(path, []byte(`
+port: 9090
+networks:
+  - name: redt
+    urls: [ws://127.0.0.1:22001, http://127.0.0.1:22000]
+    blocks: 100
+  - name: testnet
+    urls: [http://10.0.0.5:8545]
+    registry: testnet.yaml
+`), 0600)
+	assert.NoError(t, err)
+
+	cfg, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultIP, cfg.IP)
+	assert.Equal(t, int64(9090), cfg.Port)
+	assert.Equal(t, int64(defaultMaxBlockAge), cfg.MaxBlockAge)
+	assert.Len(t, cfg.Networks, 2)
+	assert.Equal(t, []string{"ws://127.0.0.1:22001", "http://127.0.0.1:22000"}, cfg.Networks[0].URLs)
+	assert.Equal(t, int64(100), cfg.Networks[0].Blocks)
+	assert.Equal(t, ConsensusIBFT, cfg.Networks[1].Consensus)
+	assert.Equal(t, int64(defaultRefresh), cfg.Networks[1].Refresh)
+	assert.Equal(t, "testnet.yaml", cfg.Networks[1].Registry)
+}
+
+func TestValidateConfig(t *testing.T) {
+	network := func(name string) NetworkConfig {
+		return NetworkConfig{Name: name, URLs: []string{"http://127.0.0.1:22000"}}
+	}
+
+	assert.Error(t, (&Config{}).Validate())
+	assert.Error(t, (&Config{Networks: []NetworkConfig{network("red t")}}).Validate())
+	assert.Error(t, (&Config{Networks: []NetworkConfig{network("redt"), network("redt")}}).Validate())
+	assert.Error(t, (&Config{Networks: []NetworkConfig{{Name: "redt"}}}).Validate())
+
+	clique := network("redt")
+	clique.Consensus = "clique"
+	assert.Error(t, (&Config{Networks: []NetworkConfig{clique}}).Validate())
+
+	windows := network("redt")
+	windows.Windows = "100,1 hour"
+	assert.Error(t, (&Config{Networks: []NetworkConfig{windows}}).Validate())
+	windows.Windows = "100,1h"
+	assert.NoError(t, (&Config{Networks: []NetworkConfig{windows}}).Validate())
+
+	first, second := network("redt"), network("testnet")
+	first.State, second.State = "state.json", "state.json"
+	assert.Error(t, (&Config{Networks: []NetworkConfig{first, second}}).Validate())
+	second.State = "testnet.json"
+	assert.NoError(t, (&Config{Networks: []NetworkConfig{first, second}}).Validate())
+
+	first.DSN, second.DSN = "history.sqlite", "history.sqlite"
+	assert.Error(t, (&Config{Networks: []NetworkConfig{first, second}}).Validate())
+	second.DSN = "testnet.sqlite"
+	assert.NoError(t, (&Config{Networks: []NetworkConfig{first, second}}).Validate())
+
+	assert.NoError(t, (&Config{Networks: []NetworkConfig{network("redt"), network("test-net_2")}}).Validate())
+}
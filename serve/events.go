@@ -0,0 +1,340 @@
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/hesusruiz/signers/alerts"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+// The types of events published
+const (
+	EventBlock      = "block"
+	EventValidators = "validators"
+	EventAlert      = "alert"
+	EventPeers      = "peers"
+	EventStall      = "stall"
+)
+
+const (
+	// Number of past events kept to resume the stream of clients that reconnect
+	eventHistorySize = 512
+
+	// Number of events that can be queued for a subscriber before it is considered too slow
+	subscriberBufferSize = 64
+
+	// Period of the comments sent to keep the connection open through proxies
+	keepAlivePeriod = 15 * time.Second
+
+	// Period to publish the peers of the node
+	peersPeriod = 30 * time.Second
+
+	// The JSON-RPC error of the methods which do not exist, as the admin API when it is not enabled
+	rpcMethodNotFound = -32601
+)
+
+// event is a typed message for clients, with a sequential id.
+// Messages which are not events, like replies to requests, do not have an id.
+type event struct {
+	ID   uint64          `json:"id,omitempty"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// eventBus keeps the most recent events in a ring buffer and distributes new ones to the subscribers
+type eventBus struct {
+	mu          sync.Mutex
+	lastID      uint64
+	ring        []event
+	next        int
+	subscribers map[chan event]bool
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		ring:        make([]event, 0, eventHistorySize),
+		subscribers: make(map[chan event]bool),
+	}
+}
+
+// Publish assigns the next id to the event, stores it and sends it to all subscribers
+func (b *eventBus) Publish(eventType string, payload any) (event, error) {
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return event{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	ev := event{ID: b.lastID, Type: eventType, Data: data}
+
+	// Store in the ring buffer, overwriting the oldest when full
+	if len(b.ring) < eventHistorySize {
+		b.ring = append(b.ring, ev)
+	} else {
+		b.ring[b.next] = ev
+	}
+	b.next = (b.next + 1) % eventHistorySize
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			// The subscriber does not keep up, it will have to reconnect and resume
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ev, nil
+}
+
+// LastID returns the id of the most recent event published
+func (b *eventBus) LastID() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastID
+}
+
+// Subscribe returns the events stored after lastID and a channel for the new ones.
+// If lastID is too old the first event returned will not be the one following it.
+func (b *eventBus) Subscribe(lastID uint64) ([]event, chan event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Get the stored events in order, starting from the oldest
+	var missed []event
+	for i := 0; i < len(b.ring); i++ {
+		ev := b.ring[(b.next+i)%len(b.ring)]
+		if ev.ID > lastID {
+			missed = append(missed, ev)
+		}
+	}
+
+	ch := make(chan event, subscriberBufferSize)
+	b.subscribers[ch] = true
+
+	return missed, ch
+}
+
+// Unsubscribe stops sending events to the channel
+func (b *eventBus) Unsubscribe(ch chan event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers[ch] {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// serveEvents is the handler for the Server-Sent Events stream. Clients reconnecting with the
+// Last-Event-ID header receive the events they missed, if still in the buffer.
+func (s *Server) serveEvents(c echo.Context) error {
+
+	var lastID uint64
+	lastIDStr := c.Request().Header.Get("Last-Event-ID")
+	if len(lastIDStr) == 0 {
+		// Allow also a query parameter, for clients that can not set headers
+		lastIDStr = c.QueryParam("lastEventId")
+	}
+	if len(lastIDStr) > 0 {
+		var err error
+		lastID, err = strconv.ParseUint(lastIDStr, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid Last-Event-ID")
+		}
+	}
+
+	// The peers are only sent to operators
+	role := roleOf(c)
+
+	missed, ch := s.events.Subscribe(lastID)
+	defer s.events.Unsubscribe(ch)
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+
+	// Tell proxies like nginx not to buffer the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, ev := range missed {
+		if !visibleEvent(ev, role) {
+			continue
+		}
+		if err := writeEvent(w, ev); err != nil {
+			return nil
+		}
+	}
+	w.Flush()
+
+	keepAlive := time.NewTicker(keepAlivePeriod)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+
+		case <-c.Request().Context().Done():
+			return nil
+
+		case ev, ok := <-ch:
+			if !ok {
+				// We were too slow, the client will reconnect and resume from the last event
+				return nil
+			}
+			if !visibleEvent(ev, role) {
+				continue
+			}
+			if err := writeEvent(w, ev); err != nil {
+				return nil
+			}
+			w.Flush()
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+
+		}
+	}
+}
+
+// visibleEvent is false for the events the role of the client can not receive
+func visibleEvent(ev event, role string) bool {
+	return ev.Type != EventPeers || hasRole(role, RoleOperator)
+}
+
+func writeEvent(w *echo.Response, ev event) error {
+	_, err := fmt.Fprintf(w, "id: %v\nevent: %v\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+	return err
+}
+
+// apiValidatorsChange is the payload of the validators event
+type apiValidatorsChange struct {
+	Block      int64          `json:"block"`
+	Added      []apiValidator `json:"added"`
+	Removed    []apiValidator `json:"removed"`
+	Validators []apiValidator `json:"validators"`
+}
+
+// publish sends the event to the SSE stream and to the WebSocket clients subscribed to its topic.
+// If validator is not zero, the event is also sent to the clients subscribed to the validator.
+func (s *Server) publish(eventType string, payload any, validator common.Address) {
+
+	ev, err := s.events.Publish(eventType, payload)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	message, err := json.Marshal(ev)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	s.hub.Publish(eventTopics[eventType], validator, message)
+}
+
+// Start implements redt.BlockObserver
+func (s *Server) Start(rt *redt.RedTNode) {}
+
+// BlockProcessed implements redt.BlockObserver, publishing the block to the clients
+func (s *Server) BlockProcessed(r *redt.BlockReport) {
+
+	s.mu.Lock()
+	s.lastBlock = r
+	s.mu.Unlock()
+
+	s.publish(EventBlock, r, common.Address{})
+
+	// The activity of each validator, for the WebSocket clients subscribed to specific validators
+	for _, val := range append(append([]redt.Validator{}, r.Signers...), r.Missing...) {
+		activity := validatorActivity(r, val.Address)
+		activity.Operator = val.Operator
+		s.sendToValidator(val.Address, activity)
+	}
+}
+
+// Notify implements alerts.Notifier, publishing the alert to the clients
+func (s *Server) Notify(a *alerts.Alert) error {
+	var validator common.Address
+	if common.IsHexAddress(a.Validator) {
+		validator = common.HexToAddress(a.Validator)
+	}
+	s.publish(EventAlert, a, validator)
+	return nil
+}
+
+// publishPeers periodically publishes the peers of the node. It ends if the admin API is not
+// enabled in the node, and the other errors are logged once until the peers are retrieved again.
+func (s *Server) publishPeers() {
+	ticker := time.NewTicker(peersPeriod)
+	defer ticker.Stop()
+
+	failing := false
+	for range ticker.C {
+		report, err := s.peersReport()
+		if isMethodNotFound(err) {
+			log.Warnf("the peers are not published, as the admin API is not available: %v", err)
+			return
+		}
+		if err != nil {
+			if !failing {
+				log.Warnf("retrieving peers: %v", err)
+				failing = true
+			}
+			continue
+		}
+		if failing {
+			log.Info("retrieving peers again")
+			failing = false
+		}
+		s.publish(EventPeers, report, common.Address{})
+	}
+}
+
+// isMethodNotFound is true for the errors of the node when the method does not exist or is not enabled
+func isMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcMethodNotFound
+}
+
+// refreshValidators reloads the validator set and publishes an event if it changed
+func (s *Server) refreshValidators(number int64) {
+
+	added, removed, err := s.rt.RefreshValidators()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	change := apiValidatorsChange{
+		Block:      number,
+		Added:      s.apiValidators(added),
+		Removed:    s.apiValidators(removed),
+		Validators: s.apiValidators(s.rt.Validators()),
+	}
+	log.Infof("validator set changed at block %v: %v added, %v removed", number, len(added), len(removed))
+
+	s.publish(EventValidators, change, common.Address{})
+}
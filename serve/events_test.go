@@ -0,0 +1,50 @@
+package serve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBusResume(t *testing.T) {
+	b := newEventBus()
+
+	for i := 0; i < eventHistorySize+10; i++ {
+		b.Publish(EventBlock, i)
+	}
+
+	// Resuming from a recent id returns only the following events
+	missed, ch := b.Subscribe(eventHistorySize + 5)
+	assert.Len(t, missed, 5)
+	assert.Equal(t, uint64(eventHistorySize+6), missed[0].ID)
+	assert.Equal(t, uint64(eventHistorySize+10), missed[4].ID)
+
+	// Resuming from an id no longer in the buffer returns all the stored events, oldest first
+	missed, ch2 := b.Subscribe(1)
+	assert.Len(t, missed, eventHistorySize)
+	assert.Equal(t, uint64(11), missed[0].ID)
+
+	// New events are delivered to the subscribers
+	b.Publish(EventAlert, "test")
+	ev := <-ch
+	assert.Equal(t, EventAlert, ev.Type)
+	assert.Equal(t, `"test"`, string(ev.Data))
+
+	b.Unsubscribe(ch)
+	b.Unsubscribe(ch2)
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestEventBusSlowSubscriber(t *testing.T) {
+	b := newEventBus()
+	_, ch := b.Subscribe(0)
+
+	// A subscriber that does not read is dropped when its buffer is full
+	for i := 0; i <= subscriberBufferSize; i++ {
+		b.Publish(EventBlock, i)
+	}
+	for range ch {
+	}
+	assert.Empty(t, b.subscribers)
+}
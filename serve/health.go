@@ -0,0 +1,128 @@
+package serve
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	healthPath    = "/healthz"
+	readinessPath = "/readyz"
+)
+
+// apiHealth is the reply of the liveness probe
+type apiHealth struct {
+	Status        string `json:"status"`
+	UptimeSeconds int64  `json:"uptimeSeconds"`
+}
+
+// apiCheck is the result of one of the readiness checks
+type apiCheck struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message"`
+}
+
+// apiReadiness is the reply of the readiness probe, with the details of each check
+type apiReadiness struct {
+	Status string              `json:"status"`
+	Checks map[string]apiCheck `json:"checks"`
+}
+
+// registerHealth adds the probes for the orchestrator. The liveness probe only tells that the
+// process answers, and the readiness probe that it is receiving blocks from the nodes of all the networks.
+func registerHealth(e *echo.Echo, servers []*Server) {
+	started := time.Now()
+
+	e.GET(healthPath, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, apiHealth{
+			Status:        "ok",
+			UptimeSeconds: int64(time.Since(started).Seconds()),
+		})
+	})
+
+	e.GET(readinessPath, func(c echo.Context) error {
+		return readyz(c, servers)
+	})
+}
+
+func readyz(c echo.Context, servers []*Server) error {
+	ready := readiness(servers, time.Now())
+
+	status := http.StatusOK
+	if ready.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, ready)
+}
+
+// readiness performs the checks of all the networks. With several networks, the names of the checks
+// are prefixed with the name of the network, like "redt/upstream".
+func readiness(servers []*Server, now time.Time) *apiReadiness {
+	if len(servers) == 1 {
+		return servers[0].readiness(now)
+	}
+
+	ready := &apiReadiness{Status: "ok", Checks: map[string]apiCheck{}}
+	for _, s := range servers {
+		r := s.readiness(now)
+		for name, check := range r.Checks {
+			ready.Checks[s.name+"/"+name] = check
+		}
+		if r.Status != "ok" {
+			ready.Status = "fail"
+		}
+	}
+	return ready
+}
+
+// readiness performs the checks of the network at the given time
+func (s *Server) readiness(now time.Time) *apiReadiness {
+
+	ready := &apiReadiness{Status: "ok", Checks: map[string]apiCheck{}}
+	check := func(name string, ok bool, message string) {
+		ready.Checks[name] = apiCheck{OK: ok, Message: message}
+		if !ok {
+			ready.Status = "fail"
+		}
+	}
+
+	// The connection with the node
+	if s.source != nil && s.source.Connected() {
+		check("upstream", true, "connected")
+	} else {
+		check("upstream", false, "not connected to the node")
+	}
+
+	// The blocks are being received
+	last := atomic.LoadInt64(&s.lastBlockReceived)
+	if last == 0 {
+		check("lastBlock", false, "no block received yet")
+	} else {
+		age := now.Sub(time.Unix(0, last))
+		number := atomic.LoadInt64(&s.latestNumber)
+		if age > s.maxBlockAge {
+			check("lastBlock", false, fmt.Sprintf("block %v received %v ago, more than %v", number, age.Round(time.Second), s.maxBlockAge))
+		} else {
+			check("lastBlock", true, fmt.Sprintf("block %v received %v ago", number, age.Round(time.Second)))
+		}
+	}
+
+	// The validator set is needed to calculate the signers
+	if n := len(s.rt.Validators()); n > 0 {
+		check("validators", true, fmt.Sprintf("%v validators loaded", n))
+	} else {
+		check("validators", false, "validator set not loaded")
+	}
+
+	return ready
+}
+
+// isProbe is true for the requests of the health checks, which are not logged
+func isProbe(c echo.Context) bool {
+	path := c.Request().URL.Path
+	return path == healthPath || path == readinessPath
+}
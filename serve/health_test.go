@@ -0,0 +1,50 @@
+package serve
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hesusruiz/signers/redt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadiness(t *testing.T) {
+	now := time.Now()
+	s := &Server{rt: &redt.RedTNode{}, maxBlockAge: time.Minute}
+
+	ready := s.readiness(now)
+	assert.Equal(t, "fail", ready.Status)
+	assert.False(t, ready.Checks["upstream"].OK)
+	assert.False(t, ready.Checks["lastBlock"].OK)
+	assert.False(t, ready.Checks["validators"].OK)
+
+	// A recent block
+	s.latestNumber = 100
+	s.lastBlockReceived = now.Add(-10 * time.Second).UnixNano()
+	ready = s.readiness(now)
+	assert.True(t, ready.Checks["lastBlock"].OK)
+	assert.Equal(t, "block 100 received 10s ago", ready.Checks["lastBlock"].Message)
+
+	// A block too old
+	s.lastBlockReceived = now.Add(-2 * time.Minute).UnixNano()
+	ready = s.readiness(now)
+	assert.False(t, ready.Checks["lastBlock"].OK)
+}
+
+func TestReadinessNetworks(t *testing.T) {
+	now := time.Now()
+	redtServer := &Server{name: "redt", rt: &redt.RedTNode{}, maxBlockAge: time.Minute}
+	testServer := &Server{name: "testnet", rt: &redt.RedTNode{}, maxBlockAge: time.Minute}
+
+	// A single network keeps the names of the checks
+	ready := readiness([]*Server{redtServer}, now)
+	assert.Contains(t, ready.Checks, "upstream")
+
+	// Several networks are prefixed with their names
+	testServer.latestNumber = 100
+	testServer.lastBlockReceived = now.UnixNano()
+	ready = readiness([]*Server{redtServer, testServer}, now)
+	assert.Equal(t, "fail", ready.Status)
+	assert.False(t, ready.Checks["redt/lastBlock"].OK)
+	assert.True(t, ready.Checks["testnet/lastBlock"].OK)
+}
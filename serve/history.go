@@ -0,0 +1,181 @@
+package serve
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/history"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// The default period of the charts
+	defaultHistoryPeriod = 24 * 60 * 60
+
+	// The bucket is calculated to have at most this number of points in the charts
+	defaultHistoryPoints = 240
+
+	// Smaller buckets can be requested explicitly, up to this number of points
+	maxHistoryPoints = 2000
+
+	minHistoryBucket = 60
+)
+
+// apiHistoryBlocks is the aggregated data of the blocks in a period
+type apiHistoryBlocks struct {
+	From   int64                      `json:"from"`
+	To     int64                      `json:"to"`
+	Bucket int64                      `json:"bucket"`
+	Points []history.BlockSeriesPoint `json:"points"`
+}
+
+// apiValidatorPoint is the activity of a validator in a bucket of time
+type apiValidatorPoint struct {
+	Time        int64   `json:"time"`
+	Blocks      int64   `json:"blocks"`
+	Proposals   int64   `json:"proposals"`
+	Seals       int64   `json:"seals"`
+	SealRate    float64 `json:"sealRate"`
+	MissedTurns int64   `json:"missedTurns"`
+}
+
+type apiValidatorSeries struct {
+	apiValidator
+	Points []apiValidatorPoint `json:"points"`
+}
+
+// apiHistoryValidators is the activity of the validators in a period
+type apiHistoryValidators struct {
+	From       int64                `json:"from"`
+	To         int64                `json:"to"`
+	Bucket     int64                `json:"bucket"`
+	Validators []apiValidatorSeries `json:"validators"`
+}
+
+// registerHistory adds the history page and its JSON endpoints, which read from the history database
+func (s *Server) registerHistory(pages *echo.Group, api *echo.Group) {
+	viewer := s.auth.require(RoleViewer)
+
+	pages.GET("/history", func(c echo.Context) error {
+		return s.renderPage(c, "history.html")
+	}, viewer)
+
+	api.GET("/history/blocks", s.apiHistoryBlocks, viewer)
+	api.GET("/history/validators", s.apiHistoryValidators, viewer)
+}
+
+// historyPeriod parses the period and bucket of the request, in Unix seconds.
+// By default the period is the last 24 hours, with the bucket adjusted to the period.
+func historyPeriod(c echo.Context) (from int64, to int64, bucket int64, err error) {
+
+	to = time.Now().Unix()
+	if str := c.QueryParam("to"); str != "" {
+		if to, err = strconv.ParseInt(str, 10, 64); err != nil {
+			return 0, 0, 0, echo.NewHTTPError(http.StatusBadRequest, "invalid 'to' time")
+		}
+	}
+
+	from = to - defaultHistoryPeriod
+	if str := c.QueryParam("from"); str != "" {
+		if from, err = strconv.ParseInt(str, 10, 64); err != nil || from >= to {
+			return 0, 0, 0, echo.NewHTTPError(http.StatusBadRequest, "invalid 'from' time")
+		}
+	}
+
+	bucket = (to - from + defaultHistoryPoints - 1) / defaultHistoryPoints
+	if bucket < minHistoryBucket {
+		bucket = minHistoryBucket
+	}
+	if str := c.QueryParam("bucket"); str != "" {
+		if bucket, err = strconv.ParseInt(str, 10, 64); err != nil || bucket <= 0 || (to-from)/bucket > maxHistoryPoints {
+			return 0, 0, 0, echo.NewHTTPError(http.StatusBadRequest, "invalid 'bucket' size")
+		}
+	}
+
+	return from, to, bucket, nil
+}
+
+func (s *Server) apiHistoryBlocks(c echo.Context) error {
+
+	if s.db == nil {
+		return echo.NewHTTPError(http.StatusNotImplemented, "history database not configured")
+	}
+
+	from, to, bucket, err := historyPeriod(c)
+	if err != nil {
+		return err
+	}
+
+	points, err := s.db.BlockSeries(from, to, bucket)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, apiHistoryBlocks{From: from, To: to, Bucket: bucket, Points: points})
+}
+
+func (s *Server) apiHistoryValidators(c echo.Context) error {
+
+	if s.db == nil {
+		return echo.NewHTTPError(http.StatusNotImplemented, "history database not configured")
+	}
+
+	from, to, bucket, err := historyPeriod(c)
+	if err != nil {
+		return err
+	}
+
+	blocks, err := s.db.BlockSeries(from, to, bucket)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	signers, err := s.db.SignerSeries(from, to, bucket, s.rt.Validators())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	// The validators of the current set, and any other which was active in the period
+	validators := s.rt.Validators()
+	activity := map[common.Address]map[int64]history.SignerSeriesPoint{}
+	for _, val := range validators {
+		activity[val] = map[int64]history.SignerSeriesPoint{}
+	}
+	for _, p := range signers {
+		addr := common.HexToAddress(p.Address)
+		if activity[addr] == nil {
+			activity[addr] = map[int64]history.SignerSeriesPoint{}
+			validators = append(validators, addr)
+		}
+		activity[addr][p.Time] = p
+	}
+
+	result := apiHistoryValidators{From: from, To: to, Bucket: bucket, Validators: make([]apiValidatorSeries, len(validators))}
+	for i, val := range validators {
+		series := apiValidatorSeries{
+			apiValidator: apiValidator{Address: val, Operator: s.rt.OperatorName(val)},
+			Points:       make([]apiValidatorPoint, len(blocks)),
+		}
+		for j, b := range blocks {
+			p := activity[val][b.Time]
+			series.Points[j] = apiValidatorPoint{
+				Time:        b.Time,
+				Blocks:      b.Blocks,
+				Proposals:   p.Proposals,
+				Seals:       p.Seals,
+				SealRate:    float64(p.Seals) / float64(b.Blocks),
+				MissedTurns: p.MissedTurns,
+			}
+		}
+		result.Validators[i] = series
+	}
+
+	// Sort by operator, so the colors of the charts are stable
+	sort.SliceStable(result.Validators, func(i, j int) bool {
+		return result.Validators[i].Operator < result.Validators[j].Operator
+	})
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,35 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryPeriod(t *testing.T) {
+	e := echo.New()
+	period := func(query string) (int64, int64, int64, error) {
+		req := httptest.NewRequest(http.MethodGet, "/api/history/validators?"+query, nil)
+		return historyPeriod(e.NewContext(req, httptest.NewRecorder()))
+	}
+
+	// The bucket is adjusted to the period, which can be as long as requested as the result is aggregated
+	from, to, bucket, err := period("from=0&to=864000")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{0, 864000, 3600}, []int64{from, to, bucket})
+
+	_, _, bucket, err = period("from=0&to=3600")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(minHistoryBucket), bucket)
+
+	// Too many points, or an invalid period
+	for _, query := range []string{"from=0&to=864000&bucket=60", "from=100&to=50", "to=x", "from=0&to=100&bucket=0"} {
+		_, _, _, err = period(query)
+		if assert.Error(t, err, query) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code, query)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package serve
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/gommon/log"
+)
+
+const (
+	// Number of messages that can be queued for a client before it is considered too slow
+	clientBufferSize = 16
+
+	// Time allowed to write a message to the client
+	writeWait = 10 * time.Second
+
+	// Time allowed to read the next pong message from the client
+	pongWait = 60 * time.Second
+
+	// Send pings to the client with this period. Must be less than pongWait
+	pingPeriod = (pongWait * 9) / 10
+
+	// Maximum size of the requests from the clients
+	maxRequestSize = 4096
+)
+
+// Hub keeps the set of WebSocket clients connected and sends each message to the clients
+// subscribed to its topic. All access to the set of clients is serialised through the run loop.
+type Hub struct {
+	clients    map[*wsClient]bool
+	register   chan *wsClient
+	unregister chan *wsClient
+	broadcast  chan *hubMessage
+	direct     chan *hubMessage
+}
+
+// hubMessage is a message for the clients subscribed to the topic, or to the validator if not zero.
+// If client is not nil the message is sent only to that client, whatever its subscriptions.
+type hubMessage struct {
+	topic     string
+	validator common.Address
+	client    *wsClient
+	data      []byte
+}
+
+// wsClient is a browser connected via WebSockets, with its own buffer of outgoing messages
+type wsClient struct {
+	hub    *Hub
+	server *Server
+	conn   *websocket.Conn
+	send   chan []byte
+	role   string // Limits the topics the client can subscribe to
+
+	// The subscriptions, modified by the requests of the client
+	mu         sync.Mutex
+	topics     map[string]bool
+	validators map[common.Address]bool
+}
+
+func newHub() *Hub {
+	return &Hub{
+		clients:    make(map[*wsClient]bool),
+		register:   make(chan *wsClient),
+		unregister: make(chan *wsClient),
+		broadcast:  make(chan *hubMessage),
+		direct:     make(chan *hubMessage),
+	}
+}
+
+// run processes registrations and broadcasts until the program ends
+func (h *Hub) run() {
+	for {
+		select {
+
+		case client := <-h.register:
+			h.clients[client] = true
+			log.Debugf("client connected, total: %v", len(h.clients))
+
+		case client := <-h.unregister:
+			if _, ok := h.clients[client]; ok {
+				delete(h.clients, client)
+				close(client.send)
+			}
+			log.Debugf("client disconnected, total: %v", len(h.clients))
+
+		case message := <-h.broadcast:
+			for client := range h.clients {
+				if client.wants(message) {
+					h.deliver(client, message.data)
+				}
+			}
+
+		case message := <-h.direct:
+			// The client may have disconnected in the meantime
+			if h.clients[message.client] {
+				h.deliver(message.client, message.data)
+			}
+
+		}
+	}
+}
+
+// deliver queues the data for the client. Must be called from the run loop.
+func (h *Hub) deliver(client *wsClient, data []byte) {
+	select {
+	case client.send <- data:
+	default:
+		// The client does not keep up, disconnect it so it does not block the others
+		delete(h.clients, client)
+		close(client.send)
+		log.Warnf("client too slow, disconnected")
+	}
+}
+
+// Publish sends the message to the clients subscribed to the topic, or to the validator if not zero
+func (h *Hub) Publish(topic string, validator common.Address, data []byte) {
+	h.broadcast <- &hubMessage{topic: topic, validator: validator, data: data}
+}
+
+// SendTo sends the message only to the client
+func (h *Hub) SendTo(client *wsClient, data []byte) {
+	h.direct <- &hubMessage{client: client, data: data}
+}
+
+// wants returns true if the client is subscribed to the message
+func (c *wsClient) wants(message *hubMessage) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.topics[message.topic] {
+		return true
+	}
+	return message.validator != (common.Address{}) && c.validators[message.validator]
+}
+
+// readPump processes the requests of the client until it goes away
+func (c *wsClient) readPump() {
+	defer func() {
+		c.hub.unregister <- c
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxRequestSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		_, request, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.server.handleRequest(c, request)
+	}
+}
+
+// writePump sends the queued messages to the client, and pings it periodically
+func (c *wsClient) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+
+		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				// The hub closed the channel
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package serve
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/labstack/echo/v4"
+)
+
+// apiStall is the payload of the stall event, without the validators connected to our node,
+// which are only shown to operators
+type apiStall struct {
+	Stalled        bool  `json:"stalled"`
+	LastBlock      int64 `json:"lastBlock"`
+	LastBlockTime  int64 `json:"lastBlockTime"`
+	Live           *int  `json:"live,omitempty"` // Unknown without the admin API of the node
+	Quorum         *int  `json:"quorum,omitempty"`
+	QuorumPossible *bool `json:"quorumPossible,omitempty"`
+}
+
+// apiLiveness is the state of the chain, with the liveness of the validators for operators
+type apiLiveness struct {
+	apiStall
+	Elapsed       int64                    `json:"elapsed"`
+	Validators    []redt.ValidatorLiveness `json:"validators,omitempty"`
+	LivenessError string                   `json:"livenessError,omitempty"`
+}
+
+// registerLiveness adds the liveness page and its JSON endpoint
+func (s *Server) registerLiveness(pages *echo.Group, api *echo.Group) {
+	viewer := s.auth.require(RoleViewer)
+
+	pages.GET("/liveness", func(c echo.Context) error {
+		return s.renderPage(c, "liveness.html")
+	}, viewer)
+
+	api.GET("/liveness", s.apiLiveness, viewer)
+}
+
+// apiLiveness returns the state of the stall detector, and for operators the liveness of the
+// validators retrieved now from the node
+func (s *Server) apiLiveness(c echo.Context) error {
+
+	report := s.stall.Status(time.Now())
+
+	var livenessError string
+	if hasRole(roleOf(c), RoleOperator) {
+		var err error
+		report.Liveness, err = s.rt.Liveness()
+		if err != nil {
+			livenessError = err.Error()
+		}
+	}
+
+	liveness := apiLiveness{
+		apiStall:      stallPayload(report),
+		Elapsed:       report.Elapsed,
+		LivenessError: livenessError,
+	}
+	if report.Liveness != nil {
+		liveness.Validators = report.Liveness.Validators
+	}
+	return c.JSON(http.StatusOK, liveness)
+}
+
+// stallPayload converts the report, with the number of validators connected but not which ones
+func stallPayload(report *redt.StallReport) apiStall {
+	p := apiStall{
+		Stalled:       report.Stalled,
+		LastBlock:     report.LastBlock,
+		LastBlockTime: report.LastBlockTime.Unix(),
+	}
+	if report.Liveness != nil {
+		p.Live = &report.Liveness.Live
+		p.Quorum = &report.Liveness.Quorum
+		p.QuorumPossible = &report.Liveness.QuorumPossible
+	}
+	return p
+}
+
+// StallChanged implements redt.StallObserver, publishing the changes of state to the clients
+func (s *Server) StallChanged(report *redt.StallReport) {
+	s.mu.Lock()
+	changed := report.Stalled != s.stalled
+	s.stalled = report.Stalled
+	s.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	s.publish(EventStall, stallPayload(report), common.Address{})
+}
@@ -0,0 +1,28 @@
+package serve
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hesusruiz/signers/redt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStallPayload(t *testing.T) {
+	report := &redt.StallReport{Stalled: true, LastBlock: 100, LastBlockTime: time.Unix(1000, 0), Elapsed: 45}
+
+	data, err := json.Marshal(stallPayload(report))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"stalled":true,"lastBlock":100,"lastBlockTime":1000}`, string(data))
+
+	// The number of validators connected is public, but not which ones
+	report.Liveness = &redt.Liveness{
+		Validators: []redt.ValidatorLiveness{{Operator: "Alpha", Connected: true}, {Operator: "Bravo"}},
+		Live:       1,
+		Quorum:     2,
+	}
+	data, err = json.Marshal(stallPayload(report))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"stalled":true,"lastBlock":100,"lastBlockTime":1000,"live":1,"quorum":2,"quorumPossible":false}`, string(data))
+}
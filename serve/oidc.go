@@ -0,0 +1,254 @@
+package serve
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// OIDCConfig verifies the tokens issued by an OpenID Connect provider, signed with RS256
+type OIDCConfig struct {
+	// The issuer, whose discovery document is at <issuer>/.well-known/openid-configuration
+	Issuer string `yaml:"issuer"`
+
+	// The audience the tokens must be issued for, usually the client id
+	Audience string `yaml:"audience"`
+
+	// The claim with the roles of the user, a string or a list of strings. "roles" by default.
+	RolesClaim string `yaml:"rolesClaim"`
+
+	// The role of the valid tokens without any of our roles in the claim, "viewer" by default
+	Role string `yaml:"role"`
+}
+
+const (
+	defaultRolesClaim = "roles"
+
+	// Tolerance for the differences between our clock and the one of the provider
+	clockSkew = time.Minute
+
+	// Unknown key ids reload the keys of the provider, at most once in this period
+	keysRefreshPeriod = time.Minute
+
+	oidcHTTPTimeout = 10 * time.Second
+)
+
+func (cfg *OIDCConfig) validate() error {
+	if !strings.HasPrefix(cfg.Issuer, "https://") && !strings.HasPrefix(cfg.Issuer, "http://") {
+		return fmt.Errorf("invalid issuer '%v'", cfg.Issuer)
+	}
+	if len(cfg.Audience) == 0 {
+		return fmt.Errorf("no audience")
+	}
+	if len(cfg.RolesClaim) == 0 {
+		cfg.RolesClaim = defaultRolesClaim
+	}
+	if len(cfg.Role) == 0 {
+		cfg.Role = RoleViewer
+	}
+	if _, ok := roleLevels[cfg.Role]; !ok {
+		return fmt.Errorf("unknown role '%v'", cfg.Role)
+	}
+	return nil
+}
+
+// oidcVerifier checks the signature and claims of the tokens, with the keys published by the provider
+type oidcVerifier struct {
+	cfg    *OIDCConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	lastRefresh time.Time
+}
+
+func newOIDCVerifier(cfg *OIDCConfig) *oidcVerifier {
+	return &oidcVerifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: oidcHTTPTimeout},
+		keys:   map[string]*rsa.PublicKey{},
+	}
+}
+
+// verify returns the identity of a valid token
+func (v *oidcVerifier) verify(token string) (*identity, error) {
+	return v.verifyAt(token, time.Now())
+}
+
+func (v *oidcVerifier) verifyAt(token string, now time.Time) (*identity, error) {
+
+	// The time claims are checked by identity, tolerating the differences with the clock of the provider
+	parser := &jwt.Parser{ValidMethods: []string{jwt.SigningMethodRS256.Alg()}, SkipClaimsValidation: true}
+
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	return v.identity(claims, now)
+}
+
+// identity checks the claims of a token with a valid signature
+func (v *oidcVerifier) identity(claims jwt.MapClaims, now time.Time) (*identity, error) {
+
+	if !claims.VerifyIssuer(v.cfg.Issuer, true) {
+		return nil, fmt.Errorf("token issued by '%v'", claims["iss"])
+	}
+
+	if !claims.VerifyAudience(v.cfg.Audience, true) {
+		return nil, fmt.Errorf("token not issued for '%v'", v.cfg.Audience)
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, fmt.Errorf("token without expiration")
+	}
+	if !claims.VerifyExpiresAt(now.Add(-clockSkew).Unix(), true) {
+		return nil, fmt.Errorf("token expired")
+	}
+	if !claims.VerifyNotBefore(now.Add(clockSkew).Unix(), false) {
+		return nil, fmt.Errorf("token not valid yet")
+	}
+
+	// The highest of our roles in the claim
+	role := v.cfg.Role
+	for r := range stringsClaim(claims[v.cfg.RolesClaim]) {
+		if level, ok := roleLevels[r]; ok && level > roleLevels[role] {
+			role = r
+		}
+	}
+
+	name, _ := claims["preferred_username"].(string)
+	if len(name) == 0 {
+		name, _ = claims["sub"].(string)
+	}
+
+	return &identity{Name: name, Role: role}, nil
+}
+
+// stringsClaim returns the values of a claim which can be a string or a list of strings
+func stringsClaim(claim any) map[string]bool {
+	values := map[string]bool{}
+	switch c := claim.(type) {
+	case string:
+		values[c] = true
+	case []any:
+		for _, item := range c {
+			if s, ok := item.(string); ok {
+				values[s] = true
+			}
+		}
+	}
+	return values
+}
+
+// key returns the public key with the given id, reloading the keys of the provider if it is unknown.
+// The keys are retrieved without holding the lock, so the other tokens are verified meanwhile.
+func (v *oidcVerifier) key(kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	key := v.keys[kid]
+	refresh := key == nil && time.Since(v.lastRefresh) >= keysRefreshPeriod
+	if refresh {
+		v.lastRefresh = time.Now()
+	}
+	v.mu.Unlock()
+
+	if key != nil {
+		return key, nil
+	}
+	if !refresh {
+		return nil, fmt.Errorf("unknown token key '%v'", kid)
+	}
+
+	keys, err := v.fetchKeys()
+	if err != nil {
+		return nil, fmt.Errorf("retrieving the keys of the provider: %w", err)
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+
+	if key := keys[kid]; key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown token key '%v'", kid)
+}
+
+// jwk is a public key of the provider. Only the RSA keys are used.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// fetchKeys retrieves the keys from the url in the discovery document of the provider
+func (v *oidcVerifier) fetchKeys() (map[string]*rsa.PublicKey, error) {
+
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	err := v.getJSON(strings.TrimSuffix(v.cfg.Issuer, "/")+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return nil, err
+	}
+	if discovery.Issuer != v.cfg.Issuer {
+		return nil, fmt.Errorf("the discovery document is for issuer '%v'", discovery.Issuer)
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	err = v.getJSON(discovery.JWKSURI, &jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (len(k.Use) > 0 && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+func (v *oidcVerifier) getJSON(url string, result any) error {
+	resp, err := v.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v: status %v", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
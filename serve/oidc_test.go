@@ -0,0 +1,106 @@
+package serve
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// signToken creates a token signed with RS256
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(claims))
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	return signed
+}
+
+func TestOIDCVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	// The provider publishes its discovery document and keys
+	var issuer string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": issuer + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []jwk{{
+			Kty: "RSA",
+			Kid: "key1",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	provider := httptest.NewServer(mux)
+	defer provider.Close()
+	issuer = provider.URL
+
+	cfg := &OIDCConfig{Issuer: issuer, Audience: "signers"}
+	assert.NoError(t, cfg.validate())
+	v := newOIDCVerifier(cfg)
+
+	now := time.Now()
+	claims := func() map[string]any {
+		return map[string]any{
+			"iss":                issuer,
+			"aud":                []string{"signers", "other"},
+			"sub":                "1234",
+			"preferred_username": "alice",
+			"exp":                now.Add(time.Hour).Unix(),
+			"roles":              []string{"offline_access", RoleOperator},
+		}
+	}
+
+	id, err := v.verifyAt(signToken(t, key, "key1", claims()), now)
+	assert.NoError(t, err)
+	assert.Equal(t, &identity{Name: "alice", Role: RoleOperator}, id)
+
+	// Without any of our roles, the default one
+	c := claims()
+	delete(c, "roles")
+	id, err = v.verifyAt(signToken(t, key, "key1", c), now)
+	assert.NoError(t, err)
+	assert.Equal(t, RoleViewer, id.Role)
+
+	// Invalid claims
+	c = claims()
+	c["aud"] = "other"
+	_, err = v.verifyAt(signToken(t, key, "key1", c), now)
+	assert.Error(t, err)
+
+	c = claims()
+	c["iss"] = "https://evil.example.com"
+	_, err = v.verifyAt(signToken(t, key, "key1", c), now)
+	assert.Error(t, err)
+
+	_, err = v.verifyAt(signToken(t, key, "key1", claims()), now.Add(2*time.Hour))
+	assert.Error(t, err)
+
+	// Other algorithms are rejected
+	hmac, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(claims())).SignedString([]byte("secret"))
+	assert.NoError(t, err)
+	_, err = v.verifyAt(hmac, now)
+	assert.Error(t, err)
+
+	// Signed with another key
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	_, err = v.verifyAt(signToken(t, other, "key1", claims()), now)
+	assert.Error(t, err)
+
+	// Unknown key ids do not reload the keys continuously
+	_, err = v.verifyAt(signToken(t, key, "key2", claims()), now)
+	assert.Error(t, err)
+}
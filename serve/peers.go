@@ -0,0 +1,125 @@
+package serve
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/labstack/echo/v4"
+)
+
+// apiPeer is a node connected to ours, with the validator it corresponds to if it is in the registry
+type apiPeer struct {
+	ID            string        `json:"id"`
+	Name          string        `json:"name"` // The client version
+	Enode         string        `json:"enode"`
+	RemoteAddress string        `json:"remoteAddress"`
+	Inbound       bool          `json:"inbound"`
+	Validator     *apiValidator `json:"validator,omitempty"`
+}
+
+// apiPeers are the peers of our node, and the validators of the current set it is not connected to
+type apiPeers struct {
+	Time         int64          `json:"time"`
+	Self         *apiValidator  `json:"self,omitempty"` // If our node is a validator
+	Peers        []apiPeer      `json:"peers"`
+	Disconnected []apiValidator `json:"disconnected"`
+}
+
+// registerPeers adds the peers page and its JSON endpoint, only for operators
+func (s *Server) registerPeers(pages *echo.Group, api *echo.Group) {
+	operator := s.auth.require(RoleOperator)
+
+	pages.GET("/peers", func(c echo.Context) error {
+		return s.renderPage(c, "peers.html")
+	}, operator)
+
+	api.GET("/peers", s.apiPeers, operator)
+}
+
+func (s *Server) apiPeers(c echo.Context) error {
+	report, err := s.peersReport()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// peersReport retrieves the peers from the node and maps them to the registry of validators
+func (s *Server) peersReport() (*apiPeers, error) {
+
+	peers, err := s.rt.Peers()
+	if err != nil {
+		return nil, err
+	}
+
+	// Our own enode is optional, it is only used to avoid reporting ourselves as disconnected
+	var selfEnode string
+	if ni, err := s.rt.NodeInfo(); err == nil {
+		selfEnode = ni.Enode
+	}
+
+	report := buildPeersReport(peers, selfEnode, s.rt.KnownValidators(), s.rt.Validators())
+	report.Time = time.Now().Unix()
+
+	return report, nil
+}
+
+// buildPeersReport maps the peers to the validators by the public key in their enodes
+func buildPeersReport(peers []*p2p.PeerInfo, selfEnode string, known []*redt.ValInfo, valSet []common.Address) *apiPeers {
+
+	report := &apiPeers{
+		Peers:        make([]apiPeer, len(peers)),
+		Disconnected: []apiValidator{},
+	}
+
+	// Index the registry by node id, which is derived from the public key
+	registry := map[string]*redt.ValInfo{}
+	for _, item := range known {
+		if id, err := enodeID(item.Enode); err == nil {
+			registry[id.String()] = item
+		}
+	}
+
+	connected := map[common.Address]bool{}
+	for i, p := range peers {
+		report.Peers[i] = apiPeer{
+			ID:            p.ID,
+			Name:          p.Name,
+			Enode:         p.Enode,
+			RemoteAddress: p.Network.RemoteAddress,
+			Inbound:       p.Network.Inbound,
+		}
+		if id, err := enodeID(p.Enode); err == nil {
+			if item := registry[id.String()]; item != nil {
+				report.Peers[i].Validator = &apiValidator{Address: item.Address, Operator: item.Operator}
+				connected[item.Address] = true
+			}
+		}
+	}
+
+	if id, err := enodeID(selfEnode); err == nil {
+		if item := registry[id.String()]; item != nil {
+			report.Self = &apiValidator{Address: item.Address, Operator: item.Operator}
+			connected[item.Address] = true
+		}
+	}
+
+	// The validators of the current set we are not connected to
+	for _, val := range valSet {
+		if connected[val] {
+			continue
+		}
+		v := apiValidator{Address: val}
+		for _, item := range known {
+			if item.Address == val {
+				v.Operator = item.Operator
+			}
+		}
+		report.Disconnected = append(report.Disconnected, v)
+	}
+
+	return report
+}
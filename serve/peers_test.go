@@ -0,0 +1,74 @@
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPeersReport(t *testing.T) {
+	// Enodes of the registry, which only differ in the host
+	enodeA := "enode://367354e3bb59d015fce31967f5dda5c17cb3b9acc5b571695f94a13f89d2a2c64c3bca28da05b6751a7384c38152752de35787d97e9b8d6062b3371b7a9305c4@188.244.90.2:21000?discport=0"
+	enodeB := "enode://3905f943ba5446eba164c07ab5f53a84ce17d74ec4d7591f6ec54b9d7608f57cae7cfdf946616385f59cfb5b910161a1f8520cb6f992bcc0d1ab932601205e91@154.62.228.6:21000?discport=0"
+	enodeC := "enode://6ee5504399ba5a6cbca15d7dd19c652017af0223f12af875044d103307cca82a8105cfb72455836bd52ba11cdbf5a752007af6000d59d146dade7f3738a4d148@185.170.96.121:21000?discport=0"
+
+	a := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	b := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	c := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	known := []*redt.ValInfo{
+		{Operator: "A", Enode: enodeA, Address: a},
+		{Operator: "B", Enode: enodeB, Address: b},
+		{Operator: "C", Enode: enodeC, Address: c},
+	}
+
+	// The peer is A, with a different address than in the registry, and a node not in the registry
+	peer := &p2p.PeerInfo{Enode: "enode://367354e3bb59d015fce31967f5dda5c17cb3b9acc5b571695f94a13f89d2a2c64c3bca28da05b6751a7384c38152752de35787d97e9b8d6062b3371b7a9305c4@10.0.0.1:30303", Name: "Geth/v1.10"}
+	peer.Network.Inbound = true
+	peer.Network.RemoteAddress = "10.0.0.1:30303"
+	other := &p2p.PeerInfo{Enode: "enode://7adf7393d3d75978b3d9bf2f78436bb070e1c19eff20eb2eef07dc8293293c4ecbbbcca5a2f84ee6ca9331e8efe7d7d5662ed1f92bb96a6bd0e850715b45ed6d@20.107.215.166:21000"}
+
+	report := buildPeersReport([]*p2p.PeerInfo{peer, other}, enodeB, known, []common.Address{a, b, c})
+
+	assert.Len(t, report.Peers, 2)
+	assert.Equal(t, &apiValidator{Address: a, Operator: "A"}, report.Peers[0].Validator)
+	assert.True(t, report.Peers[0].Inbound)
+	assert.Equal(t, "10.0.0.1:30303", report.Peers[0].RemoteAddress)
+	assert.Nil(t, report.Peers[1].Validator)
+
+	// We are B, and we are not connected to C
+	assert.Equal(t, &apiValidator{Address: b, Operator: "B"}, report.Self)
+	assert.Equal(t, []apiValidator{{Address: c, Operator: "C"}}, report.Disconnected)
+}
+
+func TestPeersMethodNotFound(t *testing.T) {
+
+	// The admin API is not enabled in the node, and the rest of methods fail
+	node := fakeNode(t, func(method string, params []json.RawMessage) (any, error) {
+		switch method {
+		case "istanbul_getValidators":
+			return []string{}, nil
+		case "admin_peers":
+			return nil, rpcError{code: rpcMethodNotFound, message: "the method admin_peers does not exist/is not available"}
+		}
+		return nil, errors.New("internal error")
+	})
+	defer node.Close()
+
+	rt, err := redt.NewRedTNodeWithRegistry(node.URL, nil)
+	assert.NoError(t, err)
+	s := &Server{rt: rt}
+
+	_, err = s.peersReport()
+	assert.True(t, isMethodNotFound(err))
+
+	_, err = rt.NodeInfo()
+	assert.Error(t, err)
+	assert.False(t, isMethodNotFound(err))
+	assert.False(t, isMethodNotFound(nil))
+}
@@ -0,0 +1,271 @@
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/alerts"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/labstack/gommon/log"
+)
+
+// The topics that WebSocket clients can subscribe to. In addition, a client can subscribe
+// to a specific validator with "validator:<address>", receiving its activity in each block
+// and its alerts.
+const (
+	TopicBlocks     = "blocks"
+	TopicValidators = "validators"
+	TopicAlerts     = "alerts"
+	TopicPeers      = "peers"
+	TopicTable      = "table" // The HTML table displayed by the bundled page
+
+	validatorTopicPrefix = "validator:"
+)
+
+// The types of messages sent only to WebSocket clients, in addition to the events
+const (
+	MessageValidator     = "validator"
+	MessageTable         = "table"
+	MessageSnapshot      = "snapshot"
+	MessageSubscriptions = "subscriptions"
+	MessageError         = "error"
+)
+
+// The actions that WebSocket clients can request
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+	ActionSnapshot    = "snapshot"
+)
+
+// eventTopics is the topic of each type of event
+var eventTopics = map[string]string{
+	EventBlock:      TopicBlocks,
+	EventValidators: TopicValidators,
+	EventAlert:      TopicAlerts,
+	EventPeers:      TopicPeers,
+	EventStall:      TopicBlocks,
+}
+
+var knownTopics = map[string]bool{
+	TopicBlocks:     true,
+	TopicValidators: true,
+	TopicAlerts:     true,
+	TopicPeers:      true,
+	TopicTable:      true,
+}
+
+// wsRequest is a message from a WebSocket client, like
+//
+//	{"action": "subscribe", "topics": ["blocks", "validator:0x1234..."]}
+type wsRequest struct {
+	Action string   `json:"action"`
+	Topics []string `json:"topics,omitempty"`
+}
+
+// apiValidatorActivity is the participation of a validator in a block
+type apiValidatorActivity struct {
+	Block      int64          `json:"block"`
+	Address    common.Address `json:"address"`
+	Operator   string         `json:"operator"`
+	Proposed   bool           `json:"proposed"`
+	Signed     bool           `json:"signed"`
+	MissedTurn bool           `json:"missedTurn"`
+}
+
+// apiSnapshot is the current state, sent to new clients and to the ones requesting it. Events after
+// LastEventID can be received from the SSE stream to continue from the snapshot.
+type apiSnapshot struct {
+	LastEventID  uint64                `json:"lastEventId"`
+	Block        *redt.BlockReport     `json:"block"`
+	NextProposer *apiValidator         `json:"nextProposer"`
+	Validators   []redt.ValidatorStats `json:"validators"`
+	Alerts       []alerts.Alert        `json:"alerts"`
+}
+
+// apiSubscriptions is the reply to subscribe and unsubscribe requests
+type apiSubscriptions struct {
+	Topics []string `json:"topics"`
+}
+
+// apiError is the reply to invalid requests
+type apiError struct {
+	Message string `json:"message"`
+}
+
+// validatorActivity calculates the participation of the validator in the block
+func validatorActivity(r *redt.BlockReport, val common.Address) *apiValidatorActivity {
+	return &apiValidatorActivity{
+		Block:      r.Number,
+		Address:    val,
+		Proposed:   r.Proposer.Address == val,
+		Signed:     r.Signed(val),
+		MissedTurn: r.MissedTurnOf(val),
+	}
+}
+
+// newMessage builds a message which is not an event, so it does not have an id
+func newMessage(messageType string, payload any) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(event{Type: messageType, Data: data})
+}
+
+// sendToValidator sends the activity only to the WebSocket clients subscribed to the validator
+func (s *Server) sendToValidator(val common.Address, activity *apiValidatorActivity) {
+	message, err := newMessage(MessageValidator, activity)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	s.hub.Publish("", val, message)
+}
+
+// reply sends a message only to the client
+func (s *Server) reply(c *wsClient, messageType string, payload any) {
+	message, err := newMessage(messageType, payload)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	s.hub.SendTo(c, message)
+}
+
+// handleRequest processes a message received from a WebSocket client
+func (s *Server) handleRequest(c *wsClient, data []byte) {
+
+	var req wsRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		s.reply(c, MessageError, apiError{Message: "invalid request: " + err.Error()})
+		return
+	}
+
+	switch req.Action {
+
+	case ActionSubscribe, ActionUnsubscribe:
+		topics, validators, err := parseTopics(req.Topics)
+		if err != nil {
+			s.reply(c, MessageError, apiError{Message: err.Error()})
+			return
+		}
+		if req.Action == ActionSubscribe && containsTopic(topics, TopicPeers) && !hasRole(c.role, RoleOperator) {
+			s.reply(c, MessageError, apiError{Message: fmt.Sprintf("the topic '%v' requires the %v role", TopicPeers, RoleOperator)})
+			return
+		}
+		c.updateSubscriptions(req.Action == ActionSubscribe, topics, validators)
+		s.reply(c, MessageSubscriptions, apiSubscriptions{Topics: c.subscriptions()})
+
+		// The bundled page displays the last table immediately, without waiting for the next block
+		if req.Action == ActionSubscribe && containsTopic(topics, TopicTable) {
+			s.mu.Lock()
+			table := s.lastTable
+			s.mu.Unlock()
+			if len(table) > 0 {
+				s.reply(c, MessageTable, table)
+			}
+		}
+
+	case ActionSnapshot:
+		s.reply(c, MessageSnapshot, s.snapshot())
+
+	default:
+		s.reply(c, MessageError, apiError{Message: fmt.Sprintf("unknown action '%v'", req.Action)})
+
+	}
+}
+
+// parseTopics validates the topics of a request, separating the ones of specific validators
+func parseTopics(list []string) (topics []string, validators []common.Address, err error) {
+	for _, t := range list {
+		if strings.HasPrefix(t, validatorTopicPrefix) {
+			addr := strings.TrimPrefix(t, validatorTopicPrefix)
+			if !common.IsHexAddress(addr) {
+				return nil, nil, fmt.Errorf("invalid address in topic '%v'", t)
+			}
+			validators = append(validators, common.HexToAddress(addr))
+			continue
+		}
+		if !knownTopics[t] {
+			return nil, nil, fmt.Errorf("unknown topic '%v'", t)
+		}
+		topics = append(topics, t)
+	}
+	return topics, validators, nil
+}
+
+func containsTopic(topics []string, topic string) bool {
+	for _, t := range topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// updateSubscriptions adds or removes the topics and validators of the client
+func (c *wsClient) updateSubscriptions(subscribe bool, topics []string, validators []common.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, t := range topics {
+		if subscribe {
+			c.topics[t] = true
+		} else {
+			delete(c.topics, t)
+		}
+	}
+	for _, val := range validators {
+		if subscribe {
+			c.validators[val] = true
+		} else {
+			delete(c.validators, val)
+		}
+	}
+}
+
+// subscriptions returns the current topics of the client, sorted
+func (c *wsClient) subscriptions() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := make([]string, 0, len(c.topics)+len(c.validators))
+	for t := range c.topics {
+		list = append(list, t)
+	}
+	for val := range c.validators {
+		list = append(list, validatorTopicPrefix+val.Hex())
+	}
+	sort.Strings(list)
+
+	return list
+}
+
+// snapshot returns the current state of the blockchain and the validators
+func (s *Server) snapshot() *apiSnapshot {
+
+	// Get the id first, so no event is lost if the client continues from it
+	snap := &apiSnapshot{
+		LastEventID: s.events.LastID(),
+		Validators:  s.rt.Stats(),
+		Alerts:      []alerts.Alert{},
+	}
+
+	s.mu.Lock()
+	snap.Block = s.lastBlock
+	s.mu.Unlock()
+
+	if next := s.rt.NextProposer(); next != (common.Address{}) {
+		snap.NextProposer = &apiValidator{Address: next, Operator: s.rt.OperatorName(next)}
+	}
+
+	if s.engine != nil {
+		snap.Alerts = s.engine.Active()
+	}
+
+	return snap
+}
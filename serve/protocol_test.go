@@ -0,0 +1,64 @@
+package serve
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTopics(t *testing.T) {
+	addr := "0x1111111111111111111111111111111111111111"
+
+	topics, validators, err := parseTopics([]string{TopicBlocks, TopicAlerts, "validator:" + addr})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{TopicBlocks, TopicAlerts}, topics)
+	assert.Equal(t, []common.Address{common.HexToAddress(addr)}, validators)
+
+	_, _, err = parseTopics([]string{"unknown"})
+	assert.Error(t, err)
+
+	_, _, err = parseTopics([]string{"validator:0x12"})
+	assert.Error(t, err)
+}
+
+func TestClientSubscriptions(t *testing.T) {
+	val := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	c := &wsClient{topics: map[string]bool{}, validators: map[common.Address]bool{}}
+	c.updateSubscriptions(true, []string{TopicBlocks, TopicTable}, []common.Address{val})
+
+	assert.True(t, c.wants(&hubMessage{topic: TopicBlocks}))
+	assert.False(t, c.wants(&hubMessage{topic: TopicAlerts}))
+	assert.True(t, c.wants(&hubMessage{topic: TopicAlerts, validator: val}))
+	assert.False(t, c.wants(&hubMessage{topic: TopicAlerts, validator: other}))
+
+	c.updateSubscriptions(false, []string{TopicTable}, nil)
+	assert.Equal(t, []string{TopicBlocks, "validator:" + val.Hex()}, c.subscriptions())
+}
+
+func TestValidatorActivity(t *testing.T) {
+	a := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	b := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	r := &redt.BlockReport{
+		Number:           10,
+		Proposer:         redt.Validator{Address: a},
+		ExpectedProposer: &redt.Validator{Address: b},
+		MissedTurn:       true,
+		Signers:          []redt.Validator{{Address: a}},
+		Missing:          []redt.Validator{{Address: b}},
+	}
+
+	activity := validatorActivity(r, a)
+	assert.True(t, activity.Proposed)
+	assert.True(t, activity.Signed)
+	assert.False(t, activity.MissedTurn)
+
+	activity = validatorActivity(r, b)
+	assert.False(t, activity.Proposed)
+	assert.False(t, activity.Signed)
+	assert.True(t, activity.MissedTurn)
+}
@@ -0,0 +1,50 @@
+package serve
+
+import (
+	"sync"
+
+	"github.com/hesusruiz/signers/redt"
+)
+
+// Number of recent blocks kept for the pages of the validators
+const recentBlocksSize = 300
+
+// recentBlocks keeps the reports of the most recent blocks processed. It is a redt.BlockObserver,
+// added before the statistics are initialized so it also receives the blocks of the warm-up.
+type recentBlocks struct {
+	mu   sync.Mutex
+	ring []*redt.BlockReport
+	next int
+}
+
+func newRecentBlocks() *recentBlocks {
+	return &recentBlocks{ring: make([]*redt.BlockReport, 0, recentBlocksSize)}
+}
+
+// Start implements redt.BlockObserver
+func (r *recentBlocks) Start(rt *redt.RedTNode) {}
+
+// BlockProcessed implements redt.BlockObserver
+func (r *recentBlocks) BlockProcessed(report *redt.BlockReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.ring) < recentBlocksSize {
+		r.ring = append(r.ring, report)
+	} else {
+		r.ring[r.next] = report
+	}
+	r.next = (r.next + 1) % recentBlocksSize
+}
+
+// Blocks returns the recent blocks, oldest first
+func (r *recentBlocks) Blocks() []*redt.BlockReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	blocks := make([]*redt.BlockReport, len(r.ring))
+	for i := range r.ring {
+		blocks[i] = r.ring[(r.next+i)%len(r.ring)]
+	}
+	return blocks
+}
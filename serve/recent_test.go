@@ -0,0 +1,21 @@
+package serve
+
+import (
+	"testing"
+
+	"github.com/hesusruiz/signers/redt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecentBlocks(t *testing.T) {
+	r := newRecentBlocks()
+
+	for i := int64(1); i <= recentBlocksSize+5; i++ {
+		r.BlockProcessed(&redt.BlockReport{Number: i})
+	}
+
+	blocks := r.Blocks()
+	assert.Len(t, blocks, recentBlocksSize)
+	assert.Equal(t, int64(6), blocks[0].Number)
+	assert.Equal(t, int64(recentBlocksSize+5), blocks[len(blocks)-1].Number)
+}
@@ -2,19 +2,29 @@ package serve
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"html/template"
 	"io"
+	"net/http"
 	"os"
-	"text/template"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
-	"github.com/hesusruiz/signers/client"
+	"github.com/ethereum/go-ethereum/common"
+	ethertypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/hesusruiz/signers/alerts"
+	"github.com/hesusruiz/signers/history"
+	"github.com/hesusruiz/signers/metrics"
 	"github.com/hesusruiz/signers/redt"
 	"github.com/hesusruiz/signers/types"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/labstack/gommon/log"
 
-	ethertypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/gorilla/websocket"
 )
 
@@ -39,38 +49,101 @@ func (t *Template) Render(w io.Writer, name string, data interface{}, c echo.Con
 	return err
 }
 
+// Server monitors one of the networks, with its own pipeline, counters and clients
 type Server struct {
-	rt *redt.RedTNode
+	name         string
+	consensus    string
+	networks     []string // The names of all the networks served
+	rt           *redt.RedTNode
+	db           *history.Blockchain
+	hub          *Hub
+	source       redt.HeadSource
+	exporter     *metrics.Exporter
+	recent       *recentBlocks
+	events       *eventBus
+	engine       *alerts.Engine
+	stall        *redt.StallDetector
+	auth         *authenticator // nil without authentication
+	templates    *Template
+	latestNumber int64
+
+	// For the readiness checks
+	maxBlockAge       time.Duration
+	lastBlockReceived int64 // Unix nanoseconds
+
+	// The last block processed and its rendered table, sent to new clients
+	mu        sync.Mutex
+	lastBlock *redt.BlockReport
+	lastTable string
+	stalled   bool
 }
 
-func ServeSigners(url string, ip string, port int64) {
-	var err error
+// The prefix of the routes of each network, in the pages and in the JSON API
+const (
+	networksPagesPrefix = "/networks/"
+	networksAPIPrefix   = "/api/v1/networks/"
+)
 
-	serverIP := fmt.Sprintf("%v:%v", ip, port)
+// The time the requests in progress have to finish when the server is stopped
+const shutdownTimeout = 10 * time.Second
 
-	// Preprocess the template for better performance
-	t := &Template{
-		templates: template.Must(template.New("table.html").Parse(tableHTML)),
-	}
+// apiNetwork is a network served, in the list of networks of the API
+type apiNetwork struct {
+	Name        string `json:"name"`
+	Consensus   string `json:"consensus"`
+	BlockNumber int64  `json:"blockNumber"`
+}
 
-	// Create the server struct
-	server := &Server{}
+// ServeSigners runs the web server for all the networks of the configuration.
+// The first network is served at the root, and each one under /networks/{name} for the pages
+// and /api/v1/networks/{name} for the JSON API.
+func ServeSigners(cfg *Config) {
+
+	serverIP := fmt.Sprintf("%v:%v", cfg.IP, cfg.Port)
 
-	// Connect to the RedT node
-	rt, err := redt.NewRedTNode(url)
+	// Preprocess the templates for better performance
+	t, err := newTemplates(cfg.Templates)
 	if err != nil {
 		log.Fatal(err)
 		os.Exit(1)
 	}
 
-	server.rt = rt
+	// The authentication is common to all the networks
+	auth := newAuthenticator(cfg.Auth)
+
+	// Start an independent pipeline for each network. The networks which can not be started are
+	// logged and not served, so the others are available.
+	var names []string
+	var servers []*Server
+	for _, nc := range cfg.Networks {
+		server, err := newNetworkServer(nc, cfg.MaxBlockAge, t)
+		if err != nil {
+			log.Errorf("network %v: %v", nc.Name, err)
+			continue
+		}
+		if server.db != nil {
+			defer server.db.Close()
+		}
+		server.auth = auth
+		names = append(names, nc.Name)
+		servers = append(servers, server)
+	}
+	if len(servers) == 0 {
+		log.Fatal("no network can be served")
+		os.Exit(1)
+	}
+	for _, server := range servers {
+		server.networks = names
+	}
 
 	// Create an instance of web server
 	e := echo.New()
 
-	// Configure the logger
+	// Configure the logger, without the frequent requests of the orchestrator.
+	// The query is not logged, as it may contain the access token of WebSockets and events.
 	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Format: "method=${method}, uri=${uri}, status=${status}\n",
+		Format:  "method=${method}, path=${path}, status=${status}\n",
+		Skipper: isProbe,
 	}))
 	e.Logger.SetLevel(log.DEBUG)
 
@@ -80,105 +153,374 @@ func ServeSigners(url string, ip string, port int64) {
 	// Middleware for rendering with templates
 	e.Renderer = t
 
+	// The health checks for the orchestrator, for all the networks
+	registerHealth(e, servers)
+
+	// The styles and scripts of the pages, which are public
+	e.GET("/static/*", staticHandler(cfg.Templates))
+
+	// The list of networks served
+	e.GET("/api/v1/networks", func(c echo.Context) error {
+		networks := make([]apiNetwork, len(servers))
+		for i, server := range servers {
+			networks[i] = apiNetwork{
+				Name:        server.name,
+				Consensus:   server.consensus,
+				BlockNumber: atomic.LoadInt64(&server.latestNumber),
+			}
+		}
+		return c.JSON(http.StatusOK, networks)
+	}, auth.require(RoleViewer))
+
+	// The first network is also at the root, as when there was only one
+	servers[0].register(e.Group(""), e.Group("/api/v1"))
+
+	for _, server := range servers {
+		pagesPrefix := networksPagesPrefix + server.name
+		e.GET(pagesPrefix, func(c echo.Context) error {
+			return c.Redirect(http.StatusMovedPermanently, c.Request().URL.Path+"/")
+		})
+		server.register(e.Group(pagesPrefix), e.Group(networksAPIPrefix+server.name))
+	}
+
+	// Start the server listening on the specified ip:port
+	go func() {
+		if err := e.Start(serverIP); err != nil && err != http.ErrServerClosed {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	// Run until interrupted, and then save the state of the networks a last time
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	log.Info("shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		log.Error(err)
+	}
+	for _, server := range servers {
+		server.stop()
+	}
+}
+
+// stop stops receiving the blocks of the network and detecting the stalls, and saves the state file a last time
+func (s *Server) stop() {
+	if s.source != nil {
+		s.source.Stop()
+	}
+	s.stall.Stop()
+	s.rt.StopStats()
+}
+
+// newNetworkServer connects to the first node of the network which answers and starts processing its blocks
+func newNetworkServer(nc NetworkConfig, maxBlockAge int64, t *Template) (*Server, error) {
+	var err error
+
+	registry := redt.DefaultRegistry()
+	if len(nc.Registry) > 0 {
+		registry, err = redt.LoadRegistry(nc.Registry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Connect to the first node which answers
+	var url string
+	var rt *redt.RedTNode
+	for _, url = range nc.URLs {
+		rt, err = redt.NewRedTNodeWithRegistry(url, registry)
+		if err == nil {
+			break
+		}
+		log.Errorf("network %v: connecting to %v: %v", nc.Name, url, err)
+	}
+	if rt == nil {
+		return nil, fmt.Errorf("no node answers: %w", err)
+	}
+
+	// Create the server struct
+	server := &Server{
+		name:        nc.Name,
+		consensus:   nc.Consensus,
+		rt:          rt,
+		templates:   t,
+		events:      newEventBus(),
+		maxBlockAge: time.Duration(maxBlockAge) * time.Second,
+	}
+
+	// The recent blocks include the ones of the warm-up
+	server.recent = newRecentBlocks()
+	rt.AddObserver(server.recent)
+
+	// Preload the statistics with the past blocks, or restore them from the state file, before the other observers are added
+	// so they only see new blocks
+	if len(nc.Windows) > 0 {
+		windows, err := redt.ParseWindows(nc.Windows)
+		if err != nil {
+			return nil, err
+		}
+		rt.SetWindows(windows)
+	}
+	if err := rt.StartStats(nc.Blocks, nc.State); err != nil {
+		return nil, err
+	}
+	server.warmUp()
+
+	// If the network can not be served, the state is not saved periodically anymore
+	defer func() {
+		if err != nil {
+			if server.stall != nil {
+				server.stall.Stop()
+			}
+			rt.StopStats()
+			if server.db != nil {
+				server.db.Close()
+			}
+		}
+	}()
+
+	// Open the history database, if configured
+	if len(nc.DSN) > 0 {
+		server.db, err = history.Open(nc.DSN)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Evaluate the alerting rules on the blocks processed, if configured
+	if len(nc.Rules) > 0 {
+		server.engine, err = alerts.NewEngineFromFile(nc.Rules)
+		if err != nil {
+			return nil, err
+		}
+		if server.db != nil {
+			server.engine.SetHistory(server.db)
+		}
+		rt.AddObserver(server.engine)
+
+		// The alerts are also sent to the web clients
+		server.engine.AddNotifier(server)
+	}
+
+	// Publish the blocks processed as events for the web clients
+	rt.AddObserver(server)
+
+	// Start the hub distributing the block data to all the WebSocket clients
+	server.hub = newHub()
+	go server.hub.run()
+
+	// Detect when the chain stops producing blocks, telling the clients and the alerts engine
+	server.stall = redt.NewStallDetector(time.Duration(nc.BlockPeriod)*time.Second, time.Duration(nc.StallTimeout)*time.Second)
+	rt.AddObserver(server.stall)
+
+	// Start the single pipeline processing blocks from the node
+	err = server.startPipeline(url, time.Duration(nc.Refresh)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	// Publish the peers of the node periodically
+	go server.publishPeers()
+
+	return server, nil
+}
+
+// register adds the routes of the network to the groups of the pages and of the JSON API
+func (s *Server) register(pages *echo.Group, api *echo.Group) {
+
+	viewer := s.auth.require(RoleViewer)
+
 	// Calling to this route upgrades http to a WebSocket connection
-	e.GET("/ws", func(c echo.Context) error {
-		return server.serveViaWS(c, url)
-	})
+	pages.GET("/ws", s.serveViaWS, viewer)
 
-	// The root serves an HTML with Javascript to start WebSocket from the browser
-	e.GET("/", func(c echo.Context) error {
-		return renderIndex(c, rt)
-	})
+	// The JSON API
+	s.registerAPI(api)
 
-	// Start the server listening on the specified ip:port
-	e.Logger.Fatal(e.Start(serverIP))
+	// The pages of the validators
+	s.registerValidatorPages(pages, api)
+
+	// The peers of our node
+	s.registerPeers(pages, api)
+
+	// The alerts firing
+	s.registerAlerts(pages, api)
+
+	// The stall detector and the validators connected to our node
+	s.registerLiveness(pages, api)
+
+	// The charts of the history database
+	s.registerHistory(pages, api)
+
+	// The stream of events, as an alternative to WebSockets
+	pages.GET("/events", s.serveEvents, viewer)
+
+	// The metrics for Prometheus
+	pages.GET("/metrics", echo.WrapHandler(s.exporter), s.auth.require(RoleOperator))
+
+	// The root serves an HTML with Javascript to start WebSocket from the browser
+	pages.GET("/", func(c echo.Context) error {
+		return s.renderPage(c, "index.html")
+	}, viewer)
 }
 
 var (
 	upgrader = websocket.Upgrader{}
 )
 
-// serveViaWS is the HTTP server handler for WebSocket communication
-func (s *Server) serveViaWS(c echo.Context, url string) error {
+// The validator set is reloaded from the node every this number of blocks
+const validatorsRefreshBlocks = 100
 
-	var rendered bytes.Buffer
-	var data map[string]any
+// serveViaWS is the HTTP server handler for WebSocket communication.
+// It registers the client in the hub, which sends to it the messages of the topics it subscribes to.
+func (s *Server) serveViaWS(c echo.Context) error {
 
 	// Upgrade the plain HTTP connection to WebSockets
 	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
 		return err
 	}
-	defer ws.Close()
 
-	// Prepare the template for the HTML Table with the block info
-	t := template.Must(template.New("table.html").Parse(tableHTML))
+	client := &wsClient{
+		hub:        s.hub,
+		server:     s,
+		conn:       ws,
+		send:       make(chan []byte, clientBufferSize),
+		role:       roleOf(c),
+		topics:     make(map[string]bool),
+		validators: make(map[common.Address]bool),
+	}
+	s.hub.register <- client
+
+	// The client gets the current state immediately, without waiting for the next block
+	s.reply(client, MessageSnapshot, s.snapshot())
+
+	// The pumps run until the client disconnects or is too slow
+	go client.writePump()
+	go client.readPump()
+
+	return nil
+}
+
+// startPipeline subscribes to new blocks in the node and starts processing them in the background.
+// Each block is processed exactly once, and the result is sent to the WebSocket clients subscribed.
+// The blocks are received via subscriptions when the node supports them, and by polling it otherwise.
+func (s *Server) startPipeline(url string, pollInterval time.Duration) error {
 
 	// Connect to the Blockchain node at the specified URL
-	qc, err := client.NewQuorumClient(url)
+	source, err := redt.NewHeadSource(url, s.rt, pollInterval)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
-	defer qc.Stop()
 
 	// Subscribe to receive notifications when new blocks are added to the blockchain
 	// Each notification is received as a Header in the inputCh channel
 	inputCh := make(chan types.RawHeader)
-	err = qc.SubscribeChainHead(inputCh)
+	err = source.SubscribeChainHead(inputCh)
 	if err != nil {
 		log.Error(err)
+		source.Stop()
 		return err
 	}
+	s.source = source
+	log.Infof("%v: receiving the new blocks via %v", s.name, source)
 
-	// Initialize the timestamp to calculate elapsed time between blocks
-	latestTimestamp := uint64(0)
+	// The metrics are updated by the pipeline
+	s.exporter = metrics.NewExporter(s.rt, source.Connected)
 
-	// We will start calculating statistics (eg. elapsed time) on the second block
-	isFirst := true
+	go s.rt.ProcessNewBlocks(inputCh, s.processHeader)
 
-	for {
+	return nil
+}
 
-		// We block here until a new header is received on the channel
-		rawheader := <-inputCh
+// warmUp prepares the state sent to new clients from the last block included in the statistics
+func (s *Server) warmUp() {
 
-		var currentHeader *ethertypes.Header
+	number := s.rt.LastBlockProcessed()
 
-		// Get the full header, because the raw one does not have the info we need
-		currentHeader, err = s.rt.HeaderByNumber(int64(rawheader.Number))
-		if err != nil {
-			// Log the error and retry with next block
-			log.Error(err)
-			return err
-		}
+	report, err := s.blockForNumber(number)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	table, err := s.renderTable(report)
+	if err != nil {
+		log.Error(err)
+		return
+	}
 
-		if isFirst {
-			// Do not display, we just get its timestamp to start statistics
-			latestTimestamp = currentHeader.Time
-			isFirst = false
+	atomic.StoreInt64(&s.latestNumber, number)
+	atomic.StoreInt64(&s.lastBlockReceived, time.Now().UnixNano())
 
-			// Wait for the next block
-			continue
-		}
+	s.mu.Lock()
+	s.lastBlock = report
+	s.lastTable = table
+	s.mu.Unlock()
+}
 
-		// Get the signer data and accumulated statistics
-		data, latestTimestamp = s.rt.SignersForHeader(currentHeader, latestTimestamp)
+// processHeader updates the statistics and metrics with the block, and sends the table to the clients.
+// Only the errors including the block in the statistics are returned, so it is retried.
+func (s *Server) processHeader(currentHeader *ethertypes.Header) error {
 
-		// Format the data into an HTML table
-		rendered.Reset()
-		err = t.ExecuteTemplate(&rendered, "table.html", data)
-		if err != nil {
-			log.Error(err)
-			return err
-		}
+	// Include the block in the statistics, which builds its report
+	report, err := s.rt.ProcessHeader(currentHeader)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		// Already processed
+		return nil
+	}
+	atomic.StoreInt64(&s.latestNumber, report.Number)
+	atomic.StoreInt64(&s.lastBlockReceived, time.Now().UnixNano())
 
-		// Send the HTML table to the client via the WebSocket connection
-		err = ws.WriteMessage(websocket.TextMessage, rendered.Bytes())
-		if err != nil {
+	// Update the metrics
+	signers := make([]common.Address, len(report.Signers))
+	for i, v := range report.Signers {
+		signers[i] = v.Address
+	}
+	s.exporter.ObserveBlock(currentHeader, signers)
+
+	// Store the block in the history database, read by the history pages and the daily digest
+	if s.db != nil {
+		if err := s.db.StoreBlock(currentHeader, report); err != nil {
 			log.Error(err)
-			return err
 		}
+	}
+
+	// Check periodically if validators were added or removed
+	if report.Number%validatorsRefreshBlocks == 0 {
+		s.refreshValidators(report.Number)
+	}
+
+	// Format the report into an HTML table
+	table, err := s.renderTable(report)
+	if err != nil {
+		log.Error(err)
+		return nil
+	}
 
+	s.mu.Lock()
+	s.lastTable = table
+	s.mu.Unlock()
+
+	// Send the HTML table to the clients of the bundled page
+	message, err := newMessage(MessageTable, table)
+	if err != nil {
+		log.Error(err)
+		return nil
 	}
+	s.hub.Publish(TopicTable, common.Address{}, message)
+
+	return nil
+}
 
+// renderTable formats the report of a block into the HTML table of the bundled page
+func (s *Server) renderTable(report *redt.BlockReport) (string, error) {
+	var rendered bytes.Buffer
+	err := s.templates.templates.ExecuteTemplate(&rendered, "table.html", report)
+	return rendered.String(), err
 }
@@ -0,0 +1,150 @@
+package serve
+
+import (
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+// apiStripEntry is the participation of a validator in one of the recent blocks
+type apiStripEntry struct {
+	Block      int64 `json:"block"`
+	Proposed   bool  `json:"proposed"`
+	Signed     bool  `json:"signed"`
+	MissedTurn bool  `json:"missedTurn"`
+}
+
+// apiValidatorDetail is the information displayed in the page of a validator
+type apiValidatorDetail struct {
+	apiValidator
+	Enode          string              `json:"enode"`
+	InValidatorSet bool                `json:"inValidatorSet"`
+	Self           bool                `json:"self"`
+	Connected      *bool               `json:"connected"` // Unknown if the admin API is not available
+	Peer           *p2p.PeerInfo       `json:"peer,omitempty"`
+	Stats          redt.ValidatorStats `json:"stats"`
+	RecentBlocks   int                 `json:"recentBlocks"`
+	MissedSeals    []int64             `json:"missedSeals"`
+	MissedTurns    []int64             `json:"missedTurns"`
+	Strip          []apiStripEntry     `json:"strip"`
+}
+
+// registerValidatorPages adds the list of validators, the page of each one and its JSON endpoint
+func (s *Server) registerValidatorPages(pages *echo.Group, api *echo.Group) {
+	viewer := s.auth.require(RoleViewer)
+
+	pages.GET("/validators", func(c echo.Context) error {
+		return s.renderPage(c, "validators.html")
+	}, viewer)
+
+	pages.GET("/validators/:address", func(c echo.Context) error {
+		if !common.IsHexAddress(c.Param("address")) {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid address")
+		}
+		return s.renderPage(c, "validator.html")
+	}, viewer)
+
+	api.GET("/validators/:address", s.apiValidatorDetail, viewer)
+}
+
+func (s *Server) apiValidatorDetail(c echo.Context) error {
+
+	if !common.IsHexAddress(c.Param("address")) {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid address")
+	}
+	address := common.HexToAddress(c.Param("address"))
+
+	detail := &apiValidatorDetail{
+		apiValidator: apiValidator{Address: address, Operator: s.rt.OperatorName(address)},
+		Stats:        redt.ValidatorStats{Address: address, Operator: s.rt.OperatorName(address)},
+		MissedSeals:  []int64{},
+		MissedTurns:  []int64{},
+		Strip:        []apiStripEntry{},
+	}
+	if info := s.rt.ValidatorInfo(address); info != nil {
+		detail.Enode = info.Enode
+	}
+
+	for _, st := range s.rt.Stats() {
+		if st.Address == address {
+			detail.InValidatorSet = true
+			detail.Stats = st
+		}
+	}
+
+	if !detail.InValidatorSet && detail.Enode == "" {
+		return echo.NewHTTPError(http.StatusNotFound, "address is not a known validator")
+	}
+
+	// Check if our node is the validator or is connected to it, which is only for operators
+	if len(detail.Enode) > 0 && hasRole(roleOf(c), RoleOperator) {
+		s.peerStatus(detail)
+	}
+
+	// The participation in the recent blocks
+	for _, r := range s.recent.Blocks() {
+		activity := validatorActivity(r, address)
+		if r.Missed(address) {
+			detail.MissedSeals = append(detail.MissedSeals, r.Number)
+		}
+		if activity.MissedTurn {
+			detail.MissedTurns = append(detail.MissedTurns, r.Number)
+		}
+		detail.Strip = append(detail.Strip, apiStripEntry{
+			Block:      r.Number,
+			Proposed:   activity.Proposed,
+			Signed:     activity.Signed,
+			MissedTurn: activity.MissedTurn,
+		})
+	}
+	detail.RecentBlocks = len(detail.Strip)
+
+	return c.JSON(http.StatusOK, detail)
+}
+
+// peerStatus fills whether the validator is our own node or one of its peers
+func (s *Server) peerStatus(detail *apiValidatorDetail) {
+
+	id, err := enodeID(detail.Enode)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	if ni, err := s.rt.NodeInfo(); err == nil {
+		if self, err := enodeID(ni.Enode); err == nil && self == id {
+			detail.Self = true
+			return
+		}
+	}
+
+	peers, err := s.rt.Peers()
+	if err != nil {
+		log.Warnf("retrieving peers: %v", err)
+		return
+	}
+
+	connected := false
+	for _, p := range peers {
+		if peerID, err := enodeID(p.Enode); err == nil && peerID == id {
+			connected = true
+			detail.Peer = p
+			break
+		}
+	}
+	detail.Connected = &connected
+}
+
+// enodeID returns the node id of the enode URL, which is derived from its public key
+func enodeID(url string) (enode.ID, error) {
+	node, err := enode.ParseV4(url)
+	if err != nil {
+		return enode.ID{}, err
+	}
+	return node.ID(), nil
+}
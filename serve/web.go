@@ -0,0 +1,105 @@
+package serve
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/labstack/echo/v4"
+)
+
+// The templates of the pages and the static files of the dashboard are embedded in the binary,
+// so it works without access to external sites
+//
+//go:embed web
+var webFS embed.FS
+
+// The titles of the pages, which are the templates in web/templates except the layout and table
+var pageTitles = map[string]string{
+	"index.html":      "Overview",
+	"validators.html": "Validators",
+	"validator.html":  "Validator",
+	"history.html":    "History",
+	"peers.html":      "Peers of our node",
+	"alerts.html":     "Alerts",
+	"liveness.html":   "Liveness",
+}
+
+// pageData is the data used to render the pages of a network
+type pageData struct {
+	Page     string   // The name of the template
+	Title    string   // The title of the page
+	Network  string   // The network displayed
+	Base     string   // The prefix of the pages of the network
+	API      string   // The prefix of the JSON API of the network
+	Networks []string // All the networks, for the selector
+}
+
+// newTemplates parses the embedded templates. The templates in the override directory,
+// if specified, replace the embedded ones with the same name.
+func newTemplates(overrideDir string) (*Template, error) {
+
+	t, err := template.ParseFS(webFS, "web/templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(overrideDir) > 0 {
+		files, err := filepath.Glob(filepath.Join(overrideDir, "*.html"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 {
+			t, err = t.ParseFiles(files...)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &Template{templates: t}, nil
+}
+
+// staticHandler serves the static files of the dashboard. The files in the directory 'static'
+// of the override directory, if specified, replace the embedded ones with the same name.
+func staticHandler(overrideDir string) echo.HandlerFunc {
+
+	embedded, err := fs.Sub(webFS, "web/static")
+	if err != nil {
+		panic(err)
+	}
+
+	files := embedded
+	if len(overrideDir) > 0 {
+		files = overlayFS{os.DirFS(filepath.Join(overrideDir, "static")), embedded}
+	}
+
+	return echo.WrapHandler(http.StripPrefix("/static/", http.FileServer(http.FS(files))))
+}
+
+// overlayFS opens the files from the first file system, or from the second if they do not exist in the first
+type overlayFS [2]fs.FS
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o[0].Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o[1].Open(name)
+	}
+	return f, err
+}
+
+// renderPage renders one of the pages of the network
+func (s *Server) renderPage(c echo.Context, name string) error {
+	return c.Render(http.StatusOK, name, &pageData{
+		Page:     name,
+		Title:    pageTitles[name],
+		Network:  s.name,
+		Base:     networksPagesPrefix + s.name + "/",
+		API:      networksAPIPrefix + s.name,
+		Networks: s.networks,
+	})
+}
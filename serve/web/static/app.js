@@ -0,0 +1,83 @@
+// Helpers shared by the pages of the dashboard.
+// The variable 'network' with the urls of the network is defined by the layout.
+
+// The access token given in the url of a page with the parameter 'access_token', kept for the
+// other pages of the session. It is sent with the requests of the API and of the streams.
+var accessToken = (function() {
+  var token = new URLSearchParams(location.search).get('access_token')
+  if (token) {
+    sessionStorage.setItem('access_token', token)
+  }
+  return sessionStorage.getItem('access_token')
+})()
+
+// withToken adds the access token to the url of a stream, as browsers can not set its headers
+function withToken(url) {
+  if (!accessToken) {
+    return url
+  }
+  return url + (url.indexOf('?') < 0 ? '?' : '&') + 'access_token=' + encodeURIComponent(accessToken)
+}
+
+// cell creates a table cell with a text or an element
+function cell(content) {
+  var td = document.createElement('td')
+  if (content instanceof Node) {
+    td.appendChild(content)
+  } else {
+    td.textContent = content
+  }
+  return td
+}
+
+function link(href, text) {
+  var a = document.createElement('a')
+  a.href = href
+  a.textContent = text
+  return a
+}
+
+// getJSON retrieves a document of the API, failing with the message of the server
+async function getJSON(url) {
+  var resp = await fetch(url, accessToken ? {headers: {'Authorization': 'Bearer ' + accessToken}} : {})
+  var body = await resp.json()
+  if (!resp.ok) {
+    throw new Error(body.message || resp.statusText)
+  }
+  return body
+}
+
+// showError displays the error in the status line of the page
+function showError(err) {
+  document.getElementById('status').textContent = 'Error: ' + err.message
+}
+
+function formatTime(seconds) {
+  return new Date(seconds * 1000).toLocaleString()
+}
+
+// connectWS subscribes to the topics of the WebSocket of the network, reconnecting when it is closed
+function connectWS(topics, onMessage, onState) {
+  var uri = (location.protocol === 'https:' ? 'wss:' : 'ws:') + '//' + location.host + network.base + 'ws'
+
+  var ws = new WebSocket(withToken(uri))
+
+  ws.onopen = function() {
+    onState(true)
+    ws.send(JSON.stringify({action: 'subscribe', topics: topics}))
+  }
+
+  ws.onmessage = function(evt) {
+    onMessage(JSON.parse(evt.data))
+  }
+
+  ws.onclose = function() {
+    onState(false)
+    setTimeout(function() { connectWS(topics, onMessage, onState) }, 2000)
+  }
+}
+
+// openEvents receives the stream of events of the network
+function openEvents() {
+  return new EventSource(withToken(network.base + 'events'))
+}
@@ -0,0 +1,102 @@
+// Minimal SVG charts for the history page, without external dependencies.
+
+var chartColors = ['#009688', '#e91e63', '#3f51b5', '#ff9800', '#795548', '#9c27b0', '#607d8b', '#8bc34a', '#f44336', '#00bcd4', '#ffc107', '#673ab7']
+
+var svgNS = 'http://www.w3.org/2000/svg'
+
+function svgElement(name, attrs) {
+  var el = document.createElementNS(svgNS, name)
+  for (var key in attrs) {
+    el.setAttribute(key, attrs[key])
+  }
+  return el
+}
+
+// drawChart replaces the contents of the container with a chart of the series.
+// Each series is {label, data}, with a value for each label. Bar charts are stacked.
+function drawChart(container, labels, series, type) {
+  var width = Math.max(container.clientWidth, 300)
+  var height = 240
+  var margin = {left: 48, right: 8, top: 8, bottom: 24}
+  var plotWidth = width - margin.left - margin.right
+  var plotHeight = height - margin.top - margin.bottom
+
+  // The maximum of the scale, stacked for bars
+  var max = 0
+  labels.forEach(function(_, i) {
+    var total = 0
+    series.forEach(function(s) {
+      var v = s.data[i] || 0
+      total = type === 'bar' ? total + v : Math.max(total, v)
+    })
+    max = Math.max(max, total)
+  })
+  if (max === 0) {
+    max = 1
+  }
+
+  var x = function(i) { return margin.left + (labels.length > 1 ? i * plotWidth / (labels.length - 1) : 0) }
+  var y = function(v) { return margin.top + plotHeight - v * plotHeight / max }
+
+  var svg = svgElement('svg', {width: width, height: height, class: 'chart'})
+
+  // Horizontal grid with the values
+  for (var g = 0; g <= 4; g++) {
+    var value = max * g / 4
+    svg.appendChild(svgElement('line', {x1: margin.left, x2: width - margin.right, y1: y(value), y2: y(value), class: 'gridline'}))
+    var text = svgElement('text', {x: margin.left - 4, y: y(value) + 4, 'text-anchor': 'end', class: 'axis'})
+    text.textContent = Number.isInteger(value) ? value : value.toFixed(1)
+    svg.appendChild(text)
+  }
+
+  // The first and last times
+  if (labels.length > 0) {
+    var first = svgElement('text', {x: margin.left, y: height - 6, class: 'axis'})
+    first.textContent = labels[0]
+    var last = svgElement('text', {x: width - margin.right, y: height - 6, 'text-anchor': 'end', class: 'axis'})
+    last.textContent = labels[labels.length - 1]
+    svg.append(first, last)
+  }
+
+  if (type === 'bar') {
+    var barWidth = Math.max(plotWidth / Math.max(labels.length, 1) - 1, 1)
+    var base = labels.map(function() { return 0 })
+    series.forEach(function(s, n) {
+      s.data.forEach(function(v, i) {
+        if (!v) {
+          return
+        }
+        var rect = svgElement('rect', {
+          x: x(i) - barWidth / 2, y: y(base[i] + v), width: barWidth, height: y(base[i]) - y(base[i] + v),
+          fill: chartColors[n % chartColors.length]
+        })
+        var title = svgElement('title', {})
+        title.textContent = s.label + ': ' + v + ' (' + labels[i] + ')'
+        rect.appendChild(title)
+        svg.appendChild(rect)
+        base[i] += v
+      })
+    })
+  } else {
+    series.forEach(function(s, n) {
+      var points = s.data.map(function(v, i) { return x(i) + ',' + y(v || 0) }).join(' ')
+      var line = svgElement('polyline', {points: points, fill: 'none', stroke: chartColors[n % chartColors.length], 'stroke-width': 1.5})
+      var title = svgElement('title', {})
+      title.textContent = s.label
+      line.appendChild(title)
+      svg.appendChild(line)
+    })
+  }
+
+  var legend = document.createElement('div')
+  legend.className = 'legend'
+  series.forEach(function(s, n) {
+    var item = document.createElement('span')
+    var color = document.createElement('i')
+    color.style.backgroundColor = chartColors[n % chartColors.length]
+    item.append(color, s.label)
+    legend.appendChild(item)
+  })
+
+  container.replaceChildren(svg, legend)
+}
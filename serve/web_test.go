@@ -0,0 +1,122 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageTemplates(t *testing.T) {
+	tmpl, err := newTemplates("")
+	assert.NoError(t, err)
+
+	data := &pageData{
+		Network:  "testnet",
+		Base:     networksPagesPrefix + "testnet/",
+		API:      networksAPIPrefix + "testnet",
+		Networks: []string{"redt", "testnet"},
+	}
+	for name, title := range pageTitles {
+		data.Page = name
+		data.Title = title
+
+		var rendered strings.Builder
+		assert.NoError(t, tmpl.templates.ExecuteTemplate(&rendered, name, data), name)
+		assert.Contains(t, rendered.String(), `<option value="testnet" selected>`, name)
+		assert.Contains(t, rendered.String(), `api: "/api/v1/networks/testnet"`, name)
+		assert.Contains(t, rendered.String(), "<h2>"+title+"</h2>", name)
+		assert.NotContains(t, rendered.String(), "https://", name)
+	}
+
+	// The selector is not shown with a single network
+	var rendered strings.Builder
+	data.Page = "index.html"
+	data.Networks = []string{"testnet"}
+	assert.NoError(t, tmpl.templates.ExecuteTemplate(&rendered, "index.html", data))
+	assert.NotContains(t, rendered.String(), "<select")
+}
+
+func TestOverrideTemplates(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "alerts.html"), []byte(`custom {{.Network}}`), 0600))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "static"), 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "static", "style.css"), []byte(`body {}`), 0600))
+
+	tmpl, err := newTemplates(dir)
+	assert.NoError(t, err)
+
+	var rendered strings.Builder
+	assert.NoError(t, tmpl.templates.ExecuteTemplate(&rendered, "alerts.html", &pageData{Network: "redt"}))
+	assert.Equal(t, "custom redt", rendered.String())
+
+	// The other templates are the embedded ones
+	rendered.Reset()
+	assert.NoError(t, tmpl.templates.ExecuteTemplate(&rendered, "peers.html", &pageData{Network: "redt"}))
+	assert.Contains(t, rendered.String(), "Validators not connected")
+
+	// The static files are replaced in the same way
+	e := echo.New()
+	e.GET("/static/*", staticHandler(dir))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/static/style.css")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "body {}", w.Body.String())
+
+	w = get("/static/charts.js")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "function drawChart")
+}
+
+func TestRenderTable(t *testing.T) {
+	tmpl, err := newTemplates("")
+	assert.NoError(t, err)
+	s := &Server{templates: tmpl}
+
+	a := common.HexToAddress("0x01")
+	b := common.HexToAddress("0x02")
+	report := &redt.BlockReport{
+		Number:       10,
+		Interval:     5,
+		Proposer:     redt.Validator{Address: a, Operator: "Alpha"},
+		NextProposer: &redt.Validator{Address: b},
+		Counters: []redt.ReportValidator{
+			{ValidatorStats: redt.ValidatorStats{Address: a, Operator: "Alpha", Proposals: 4, Seals: 7}, Proposed: true, Signed: true},
+			{ValidatorStats: redt.ValidatorStats{Address: b, Seals: 6}},
+		},
+	}
+
+	table, err := s.renderTable(report)
+	assert.NoError(t, err)
+	assert.Contains(t, table, "Block: 10 (5 sec)")
+
+	// The current proposer and signer are highlighted, and the zero counters in red
+	assert.Contains(t, table, "<span class='w3-badge'>4</span>")
+	assert.Contains(t, table, "<span class='w3-badge'>7</span>")
+	assert.Contains(t, table, "<span class='w3-badge w3-red'>0</span>")
+	assert.Contains(t, table, "<td>6</td>")
+
+	// The validators without operator are shown with their address
+	assert.Contains(t, table, `<a href="validators/`+a.Hex()+`">Alpha</a>`)
+	assert.Contains(t, table, "Next: "+b.Hex())
+
+	// The names of the registry are escaped
+	report.Counters[1].Operator = "<script>alert(1)</script>"
+	table, err = s.renderTable(report)
+	assert.NoError(t, err)
+	assert.NotContains(t, table, "<script>")
+	assert.Contains(t, table, "&lt;script&gt;alert(1)&lt;/script&gt;")
+}
@@ -0,0 +1,290 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/redt"
+)
+
+// The ANSI escape sequences used to style the lines
+const (
+	styleReset   = "\x1b[0m"
+	styleReverse = "\x1b[7m"
+	styleBold    = "\x1b[1m"
+	styleRed     = "\x1b[31m"
+	styleYellow  = "\x1b[33m"
+	styleTitle   = "\x1b[30;46m"
+)
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// line is a line of the screen with the style applied to all of it
+type line struct {
+	text  string
+	style string
+}
+
+// render returns the lines of the screen for the given size
+func (u *UI) render(width int, height int) []line {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	blocks := u.visibleBlocks()
+	rows := u.rows()
+
+	var top []line
+
+	// The title, with the state of the view
+	title := " Signers"
+	if len(blocks) > 0 {
+		last := blocks[len(blocks)-1]
+		title += fmt.Sprintf("  block %v  %v", last.Number, last.Time.Local().Format("15:04:05"))
+	}
+	if u.nextProposer != nil && !u.paused {
+		if next := u.nextProposer(); next != (common.Address{}) {
+			title += "  next: " + u.name(next)
+		}
+	}
+	title += "  sorted by " + sortNames[u.sortColumn]
+	if u.sortDesc {
+		title += " (desc)"
+	}
+	if u.paused {
+		title += "  PAUSED"
+	}
+	if len(u.source) > 0 {
+		title += "  via " + u.source
+	}
+	if u.stall != nil {
+		title += "  STALLED"
+	}
+	top = append(top, line{title, styleTitle})
+
+	// The block times, and the stall with the elapsed time updated
+	top = append(top, line{text: blockTimes(blocks, width)})
+	if u.stall != nil {
+		stall := *u.stall
+		stall.Elapsed = int64(time.Since(stall.LastBlockTime) / time.Second)
+		top = append(top, line{"  " + stall.String(), styleRed})
+	} else {
+		top = append(top, line{})
+	}
+
+	// The validators table, which is fixed. The rolling windows show proposals, seals and missed seals.
+	var windows []string
+	if len(rows) > 0 {
+		for _, w := range rows[0].Windows {
+			windows = append(windows, fmt.Sprintf(" %16s", truncate("P/S/M "+w.Window, 16)))
+		}
+	}
+	top = append(top, line{fmt.Sprintf("  %-16s %-14s %9s %9s %9s %9s%s  %s",
+		"[1]Operator", "Address", "[2]Prop", "[3]Seals", "[4]Missed", "[5]Turns", strings.Join(windows, ""), "Last"), styleBold})
+
+	var lastBlock *redt.BlockReport
+	if len(blocks) > 0 {
+		lastBlock = blocks[len(blocks)-1]
+	}
+	selected := u.selectedValidator()
+	for _, r := range rows {
+		l := line{text: fmt.Sprintf("  %-16s %-14s %9d %9d %9d %9d%s  %s",
+			truncate(r.Operator, 16), shortAddress(r.Address), r.Proposals, r.Seals, r.MissedSeals, r.MissedTurns, windowCounters(r.Windows), lastActivity(lastBlock, r.Address))}
+		if r.Proposals == 0 || r.Seals == 0 || inactive(r.Windows) {
+			l.style = styleRed
+		}
+		if u.focus == focusTable && r.Address == selected {
+			l.style = styleReverse
+		}
+		top = append(top, l)
+	}
+	top = append(top, line{})
+
+	// The detail pane and the status line are at the bottom
+	var bottom []line
+	if u.detail != nil {
+		bottom = append(bottom, line{strings.Repeat("─", width), ""})
+		for i, d := range u.detail {
+			if i == detailLines {
+				break
+			}
+			bottom = append(bottom, line{text: d})
+		}
+	}
+	bottom = append(bottom, u.statusLine())
+
+	// The log uses the rest of the screen
+	logRows := height - len(top) - len(bottom) - 1
+	screen := append(top, line{fmt.Sprintf("  Blocks (%v)", len(blocks)), styleBold})
+	screen = append(screen, u.logLines(blocks, logRows)...)
+	screen = append(screen, bottom...)
+
+	// Truncate to the screen, keeping the top when it is too small
+	if len(screen) > height {
+		screen = screen[:height]
+	}
+	for i := range screen {
+		screen[i].text = truncate(screen[i].text, width)
+	}
+
+	return screen
+}
+
+// logLines returns the lines of the blocks visible in the log, keeping the selected one on the screen
+func (u *UI) logLines(blocks []*redt.BlockReport, rows int) []line {
+	if rows <= 0 {
+		return nil
+	}
+
+	selected := u.selectedBlockIndex(blocks)
+	if u.logSelected == 0 {
+		u.logTop = len(blocks) - rows
+	}
+	if selected < u.logTop {
+		u.logTop = selected
+	}
+	if selected >= u.logTop+rows {
+		u.logTop = selected - rows + 1
+	}
+	u.logTop = clamp(u.logTop, 0, max(len(blocks)-rows, 0))
+
+	lines := make([]line, rows)
+	for i := 0; i < rows && u.logTop+i < len(blocks); i++ {
+		index := u.logTop + i
+		r := blocks[index]
+
+		text := fmt.Sprintf("  %9d  %v  %3ds  %-16s  %2d signers", r.Number, r.Time.Local().Format("15:04:05"),
+			r.Interval, truncate(r.Proposer.Name(), 16), len(r.Signers))
+		style := ""
+		if r.MissedTurn {
+			text += "  turn missed by " + r.ExpectedProposer.Name()
+			style = styleYellow
+		}
+		if len(r.Missing) > 0 {
+			text += "  missing: " + names(r.Missing)
+			style = styleYellow
+		}
+		if r.Interval > slowBlockSeconds {
+			style = styleRed
+		}
+		if u.focus == focusLog && index == selected {
+			style = styleReverse
+		}
+		lines[i] = line{text, style}
+	}
+	return lines
+}
+
+// statusLine shows the prompt, the last message or the key bindings
+func (u *UI) statusLine() line {
+	switch {
+	case u.prompt != nil:
+		return line{"Go to block: " + *u.prompt + "_", styleReverse}
+	case len(u.message) > 0:
+		return line{u.message, styleReverse}
+	default:
+		return line{"q quit  p pause  0-5 sort  tab table/log  ↑↓ select  enter detail  esc close  g go to block  f follow", styleReverse}
+	}
+}
+
+// blockTimes is the sparkline of the intervals of the last blocks, with their average and maximum
+func blockTimes(blocks []*redt.BlockReport, width int) string {
+	label := "  Block time "
+	n := width - len(label) - 32
+	if n < 10 {
+		n = 10
+	}
+	if len(blocks) > n {
+		blocks = blocks[len(blocks)-n:]
+	}
+	return label + sparkline(blocks) + stats(blocks)
+}
+
+func sparkline(blocks []*redt.BlockReport) string {
+	var maxInterval uint64 = 1
+	for _, r := range blocks {
+		if r.Interval > maxInterval {
+			maxInterval = r.Interval
+		}
+	}
+
+	var s strings.Builder
+	for _, r := range blocks {
+		s.WriteRune(sparks[int(r.Interval*uint64(len(sparks)-1)/maxInterval)])
+	}
+	return s.String()
+}
+
+func stats(blocks []*redt.BlockReport) string {
+	var total, maxInterval uint64
+	var count int
+	for _, r := range blocks {
+		if r.Interval == 0 {
+			continue
+		}
+		total += r.Interval
+		count++
+		if r.Interval > maxInterval {
+			maxInterval = r.Interval
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("  avg %.1fs  max %vs", float64(total)/float64(count), maxInterval)
+}
+
+// windowCounters are the proposals, seals and missed seals in each window
+func windowCounters(windows []redt.WindowStats) string {
+	var s strings.Builder
+	for _, w := range windows {
+		fmt.Fprintf(&s, " %16s", fmt.Sprintf("%d/%d/%d", w.Proposals, w.Seals, w.MissedSeals))
+	}
+	return s.String()
+}
+
+// inactive is true if the validator did not sign any block in a window with blocks
+func inactive(windows []redt.WindowStats) bool {
+	for _, w := range windows {
+		if w.Blocks > 0 && w.Seals == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lastActivity tells what the validator did in the last block
+func lastActivity(r *redt.BlockReport, addr common.Address) string {
+	switch {
+	case r == nil:
+		return ""
+	case r.Proposer.Address == addr:
+		return "proposed"
+	case r.Signed(addr):
+		return "signed"
+	default:
+		return "NOT SIGNED"
+	}
+}
+
+func shortAddress(addr common.Address) string {
+	hex := addr.Hex()
+	return hex[:6] + "…" + hex[len(hex)-4:]
+}
+
+// truncate cuts the text to the given number of characters
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/term"
+)
+
+// The escape sequences to control the terminal
+const (
+	enterAltScreen = "\x1b[?1049h"
+	exitAltScreen  = "\x1b[?1049l"
+	hideCursor     = "\x1b[?25l"
+	showCursor     = "\x1b[?25h"
+	cursorHome     = "\x1b[H"
+	clearLine      = "\x1b[K"
+	clearToEnd     = "\x1b[J"
+)
+
+// The screen is also redrawn periodically, to adapt to changes in the size of the terminal
+const refreshPeriod = time.Second
+
+// IsTerminal is true if the standard input and output are a terminal, so the interface can be used
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Run shows the interface in the terminal until the user quits
+func (u *UI) Run() error {
+
+	if !IsTerminal() {
+		return errors.New("the terminal interface requires a terminal")
+	}
+
+	fd := int(os.Stdin.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, oldState)
+
+	// The log messages are shown in the status line instead of breaking the screen
+	oldLogger := log.Logger
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: u, NoColor: true, TimeFormat: "15:04:05"})
+	defer func() { log.Logger = oldLogger }()
+
+	out := bufio.NewWriter(os.Stdout)
+	out.WriteString(enterAltScreen + hideCursor)
+	defer func() {
+		out.WriteString(showCursor + exitAltScreen)
+		out.Flush()
+	}()
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+
+	ticker := time.NewTicker(refreshPeriod)
+	defer ticker.Stop()
+
+	for {
+		u.draw(out, int(os.Stdout.Fd()))
+
+		select {
+		case key, ok := <-keys:
+			if !ok || u.handleKey(key) == keyQuit {
+				return nil
+			}
+		case <-u.redraw:
+		case <-ticker.C:
+		}
+	}
+}
+
+// draw writes the whole screen
+func (u *UI) draw(out *bufio.Writer, fd int) {
+	width, height, err := term.GetSize(fd)
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	out.WriteString(cursorHome)
+	for i, l := range u.render(width, height) {
+		if i > 0 {
+			// The terminal is in raw mode, so the new line does not return the carriage
+			out.WriteString("\r\n")
+		}
+		if len(l.style) > 0 {
+			out.WriteString(l.style + l.text + clearLine + styleReset)
+		} else {
+			out.WriteString(l.text + clearLine)
+		}
+	}
+	out.WriteString(clearToEnd)
+	out.Flush()
+}
+
+// readKeys sends the names of the keys pressed, until the input is closed
+func readKeys(in io.Reader, keys chan<- string) {
+	defer close(keys)
+
+	buf := make([]byte, 64)
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			keys <- key
+		}
+	}
+}
+
+// The escape sequences of the special keys
+var escapeKeys = map[string]string{
+	"\x1b[A":  "up",
+	"\x1b[B":  "down",
+	"\x1b[C":  "right",
+	"\x1b[D":  "left",
+	"\x1b[H":  "home",
+	"\x1b[F":  "end",
+	"\x1b[1~": "home",
+	"\x1b[4~": "end",
+	"\x1b[5~": "pgup",
+	"\x1b[6~": "pgdn",
+	"\x1bOA":  "up",
+	"\x1bOB":  "down",
+	"\x1bOH":  "home",
+	"\x1bOF":  "end",
+}
+
+// parseKeys converts the bytes read from the terminal into the names of the keys
+func parseKeys(data []byte) []string {
+	var keys []string
+
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+
+		switch {
+
+		case b == 0x1b:
+			// The longest known sequence starting here, or the escape key alone
+			key := "esc"
+			length := 1
+			for seq, name := range escapeKeys {
+				if len(seq) > length && i+len(seq) <= len(data) && string(data[i:i+len(seq)]) == seq {
+					key, length = name, len(seq)
+				}
+			}
+			keys = append(keys, key)
+			i += length - 1
+
+		case b == '\r' || b == '\n':
+			keys = append(keys, "enter")
+		case b == '\t':
+			keys = append(keys, "tab")
+		case b == 0x7f || b == 0x08:
+			keys = append(keys, "backspace")
+		case b == 0x03:
+			keys = append(keys, "ctrl-c")
+		default:
+			keys = append(keys, string(rune(b)))
+
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,521 @@
+// Package tui is a full-screen terminal interface for the monitor commands. It shows a fixed table
+// with the counters of the validators, a block-time sparkline and a scrolling log of the blocks,
+// with key bindings to sort, pause, jump to a block and open the detail of a validator or block.
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/redt"
+)
+
+const (
+	// Number of blocks kept in the log
+	maxLogBlocks = 2000
+
+	// Blocks taking longer are highlighted
+	slowBlockSeconds = 5
+
+	// Maximum number of lines of the detail pane
+	detailLines = 10
+)
+
+// The columns of the validators table which can be used to sort it, selected with the keys 0 to 5
+const (
+	sortValidatorSet = iota
+	sortOperator
+	sortProposals
+	sortSeals
+	sortMissedSeals
+	sortMissedTurns
+)
+
+var sortNames = []string{"validator set", "operator", "proposals", "seals", "missed seals", "missed turns"}
+
+// The part of the screen which receives the movement keys
+const (
+	focusTable = iota
+	focusLog
+)
+
+// The result of handling a key
+const (
+	keyHandled = iota
+	keyQuit
+)
+
+// UI is the terminal interface. It implements redt.Display.
+type UI struct {
+	mu sync.Mutex
+
+	// The data of the node, replaced in the tests
+	stats        func() []redt.ValidatorStats
+	info         func(common.Address) *redt.ValInfo
+	operator     func(common.Address) string
+	nextProposer func() common.Address
+	report       func(int64) (*redt.BlockReport, error)
+
+	// The blocks received, oldest first
+	blocks []*redt.BlockReport
+
+	// The view
+	sortColumn    int
+	sortDesc      bool
+	focus         int
+	tableSelected common.Address // Zero for the first row
+	logSelected   int64          // Zero to follow the last block
+	logTop        int
+	paused        bool
+	pausedBlock   int64
+	pausedStats   []redt.ValidatorStats
+	detail        []string // The lines of the detail pane, nil if closed
+	prompt        *string  // The number typed to jump to a block, nil if not jumping
+	message       string
+	source        string            // How the blocks are received, if known
+	stall         *redt.StallReport // The last report of the stall detector, nil if not stalled
+
+	redraw chan struct{}
+}
+
+// New creates the interface, which must be added as observer of the node before running it
+func New() *UI {
+	return &UI{
+		redraw: make(chan struct{}, 1),
+	}
+}
+
+// Start is called when the interface is added as observer of the node
+func (u *UI) Start(rt *redt.RedTNode) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.stats = rt.Stats
+	u.info = rt.ValidatorInfo
+	u.operator = rt.OperatorName
+	u.nextProposer = rt.NextProposer
+	u.report = func(number int64) (*redt.BlockReport, error) {
+		_, r, err := rt.ReportForBlockNumber(number)
+		return r, err
+	}
+}
+
+// BlockProcessed adds the block to the log
+func (u *UI) BlockProcessed(r *redt.BlockReport) {
+	u.mu.Lock()
+	u.blocks = append(u.blocks, r)
+	if len(u.blocks) > maxLogBlocks {
+		u.blocks = u.blocks[len(u.blocks)-maxLogBlocks:]
+	}
+	u.mu.Unlock()
+
+	u.requestRedraw()
+}
+
+// SetSource shows in the title how the new blocks are received
+func (u *UI) SetSource(description string) {
+	u.mu.Lock()
+	u.source = description
+	u.mu.Unlock()
+
+	u.requestRedraw()
+}
+
+// StallChanged implements redt.StallObserver, showing the stall and the liveness of the validators
+func (u *UI) StallChanged(report *redt.StallReport) {
+	u.mu.Lock()
+	if report.Stalled {
+		u.stall = report
+	} else {
+		u.stall = nil
+	}
+	u.mu.Unlock()
+
+	u.requestRedraw()
+}
+
+// Write shows the log messages in the status line, as they can not be printed while the interface runs
+func (u *UI) Write(p []byte) (int, error) {
+	lines := strings.Split(strings.TrimSpace(string(p)), "\n")
+
+	u.mu.Lock()
+	u.message = lines[len(lines)-1]
+	u.mu.Unlock()
+
+	u.requestRedraw()
+	return len(p), nil
+}
+
+func (u *UI) requestRedraw() {
+	select {
+	case u.redraw <- struct{}{}:
+	default:
+	}
+}
+
+// visibleBlocks returns the blocks shown, which do not include the ones received while paused
+func (u *UI) visibleBlocks() []*redt.BlockReport {
+	if !u.paused {
+		return u.blocks
+	}
+	i := sort.Search(len(u.blocks), func(i int) bool { return u.blocks[i].Number > u.pausedBlock })
+	return u.blocks[:i]
+}
+
+// rows returns the counters of the validators in the order selected
+func (u *UI) rows() []redt.ValidatorStats {
+	var stats []redt.ValidatorStats
+	if u.paused {
+		stats = append(stats, u.pausedStats...)
+	} else if u.stats != nil {
+		stats = u.stats()
+	}
+
+	if u.sortColumn == sortValidatorSet {
+		if u.sortDesc {
+			for i, j := 0, len(stats)-1; i < j; i, j = i+1, j-1 {
+				stats[i], stats[j] = stats[j], stats[i]
+			}
+		}
+		return stats
+	}
+
+	less := func(a, b redt.ValidatorStats) bool {
+		switch u.sortColumn {
+		case sortOperator:
+			return strings.ToLower(a.Operator) < strings.ToLower(b.Operator)
+		case sortProposals:
+			return a.Proposals < b.Proposals
+		case sortSeals:
+			return a.Seals < b.Seals
+		case sortMissedSeals:
+			return a.MissedSeals < b.MissedSeals
+		default:
+			return a.MissedTurns < b.MissedTurns
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if u.sortDesc {
+			return less(stats[j], stats[i])
+		}
+		return less(stats[i], stats[j])
+	})
+
+	return stats
+}
+
+// handleKey updates the view with a key pressed by the user
+func (u *UI) handleKey(key string) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	// Typing the number of the block to jump to
+	if u.prompt != nil {
+		switch {
+		case key == "esc":
+			u.prompt = nil
+		case key == "enter":
+			u.jumpTo(*u.prompt)
+			u.prompt = nil
+		case key == "backspace":
+			if len(*u.prompt) > 0 {
+				*u.prompt = (*u.prompt)[:len(*u.prompt)-1]
+			}
+		case len(key) == 1 && key[0] >= '0' && key[0] <= '9':
+			*u.prompt += key
+		}
+		return keyHandled
+	}
+
+	u.message = ""
+
+	switch key {
+
+	case "q", "ctrl-c":
+		return keyQuit
+
+	case "esc":
+		u.detail = nil
+
+	case "0", "1", "2", "3", "4", "5":
+		column := int(key[0] - '0')
+		if column == u.sortColumn {
+			u.sortDesc = !u.sortDesc
+		} else {
+			u.sortColumn = column
+			// The counters are more interesting from the highest
+			u.sortDesc = column >= sortProposals
+		}
+
+	case "p", " ":
+		u.paused = !u.paused
+		if u.paused {
+			u.pausedBlock = 0
+			if len(u.blocks) > 0 {
+				u.pausedBlock = u.blocks[len(u.blocks)-1].Number
+			}
+			if u.stats != nil {
+				u.pausedStats = u.stats()
+			}
+		}
+
+	case "tab":
+		u.focus = (u.focus + 1) % 2
+
+	case "g":
+		prompt := ""
+		u.prompt = &prompt
+
+	case "f", "end":
+		u.logSelected = 0
+
+	case "up", "k":
+		u.move(-1)
+	case "down", "j":
+		u.move(1)
+	case "pgup":
+		u.move(-10)
+	case "pgdn":
+		u.move(10)
+	case "home":
+		u.move(-maxLogBlocks)
+
+	case "enter":
+		if u.focus == focusTable {
+			u.showValidator(u.selectedValidator())
+		} else if r := u.selectedBlock(); r != nil {
+			u.detail = u.blockDetail(r, false)
+		}
+
+	}
+
+	return keyHandled
+}
+
+// move changes the selected row of the part of the screen with the focus
+func (u *UI) move(delta int) {
+
+	if u.focus == focusTable {
+		rows := u.rows()
+		if len(rows) == 0 {
+			return
+		}
+		i := 0
+		for j, r := range rows {
+			if r.Address == u.tableSelected {
+				i = j
+			}
+		}
+		i = clamp(i+delta, 0, len(rows)-1)
+		u.tableSelected = rows[i].Address
+		return
+	}
+
+	blocks := u.visibleBlocks()
+	if len(blocks) == 0 {
+		return
+	}
+	i := u.selectedBlockIndex(blocks) + delta
+	if i >= len(blocks)-1 {
+		// Moving past the last block follows the new ones
+		u.logSelected = 0
+		return
+	}
+	u.logSelected = blocks[clamp(i, 0, len(blocks)-1)].Number
+}
+
+func (u *UI) selectedValidator() common.Address {
+	rows := u.rows()
+	for _, r := range rows {
+		if r.Address == u.tableSelected {
+			return r.Address
+		}
+	}
+	if len(rows) > 0 {
+		return rows[0].Address
+	}
+	return common.Address{}
+}
+
+// selectedBlockIndex returns the index of the selected block, the last one when following
+func (u *UI) selectedBlockIndex(blocks []*redt.BlockReport) int {
+	if u.logSelected == 0 {
+		return len(blocks) - 1
+	}
+	i := sort.Search(len(blocks), func(i int) bool { return blocks[i].Number >= u.logSelected })
+	return clamp(i, 0, len(blocks)-1)
+}
+
+func (u *UI) selectedBlock() *redt.BlockReport {
+	blocks := u.visibleBlocks()
+	if len(blocks) == 0 {
+		return nil
+	}
+	return blocks[u.selectedBlockIndex(blocks)]
+}
+
+// jumpTo selects the block in the log, or retrieves it from the node if it is not in the log
+func (u *UI) jumpTo(text string) {
+	var number int64
+	if _, err := fmt.Sscan(text, &number); err != nil || number <= 0 {
+		u.message = "invalid block number"
+		return
+	}
+
+	for _, r := range u.visibleBlocks() {
+		if r.Number == number {
+			u.focus = focusLog
+			u.logSelected = number
+			u.detail = u.blockDetail(r, false)
+			return
+		}
+	}
+
+	if u.report == nil {
+		u.message = fmt.Sprintf("block %v is not in the log", number)
+		return
+	}
+	u.message = fmt.Sprintf("retrieving block %v...", number)
+	go u.fetchBlock(number)
+}
+
+// fetchBlock retrieves a block which is not in the log and shows it in the detail pane
+func (u *UI) fetchBlock(number int64) {
+	r, err := u.report(number)
+
+	u.mu.Lock()
+	if err != nil {
+		u.message = fmt.Sprintf("block %v: %v", number, err)
+	} else {
+		u.message = ""
+		u.detail = u.blockDetail(r, true)
+	}
+	u.mu.Unlock()
+
+	u.requestRedraw()
+}
+
+// showValidator opens the detail pane with the counters and recent activity of the validator
+func (u *UI) showValidator(addr common.Address) {
+	if addr == (common.Address{}) {
+		return
+	}
+
+	var st redt.ValidatorStats
+	for _, r := range u.rows() {
+		if r.Address == addr {
+			st = r
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("Validator %v (%v)", u.name(addr), addr.Hex()),
+	}
+	if u.info != nil {
+		if info := u.info(addr); info != nil {
+			lines = append(lines, "Enode: "+info.Enode)
+		}
+	}
+	counters := fmt.Sprintf("Proposals: %v  Seals: %v  Missed seals: %v  Missed turns: %v",
+		st.Proposals, st.Seals, st.MissedSeals, st.MissedTurns)
+	for _, w := range st.Windows {
+		counters += fmt.Sprintf("  | %v: %v/%v/%v/%v", w.Window, w.Proposals, w.Seals, w.MissedSeals, w.MissedTurns)
+	}
+	lines = append(lines, counters)
+
+	// The activity in the blocks of the log: P proposed, S signed, . not signed, ! missed its turn
+	var strip strings.Builder
+	var missedSeals, missedTurns []string
+	for _, r := range u.visibleBlocks() {
+		switch {
+		case r.MissedTurnOf(addr):
+			strip.WriteString("!")
+			missedTurns = append(missedTurns, fmt.Sprint(r.Number))
+		case r.Proposer.Address == addr:
+			strip.WriteString("P")
+		case r.Signed(addr):
+			strip.WriteString("S")
+		default:
+			strip.WriteString(".")
+		}
+		if r.Missed(addr) {
+			missedSeals = append(missedSeals, fmt.Sprint(r.Number))
+		}
+	}
+	lines = append(lines,
+		"Activity (P proposed, S signed, . not signed, ! missed turn), newest last:",
+		lastRunes(strip.String(), 200),
+		"Missed seals in blocks: "+lastItems(missedSeals, 20),
+		"Missed turns in blocks: "+lastItems(missedTurns, 20),
+	)
+
+	u.detail = lines
+}
+
+// blockDetail returns the lines of the detail pane of a block
+func (u *UI) blockDetail(r *redt.BlockReport, fromNode bool) []string {
+	lines := []string{
+		fmt.Sprintf("Block %v  %v  %v sec", r.Number, r.Time.Local().Format("2006-01-02 15:04:05"), r.Interval),
+		"Hash: " + r.Hash.Hex(),
+	}
+
+	author := "Author: " + r.Proposer.Name()
+	if r.MissedTurn {
+		author += fmt.Sprintf("  (turn of %v, missed)", r.ExpectedProposer.Name())
+	}
+	lines = append(lines, author,
+		fmt.Sprintf("GasLimit: %v  GasUsed: %v", r.GasLimit, r.GasUsed),
+		fmt.Sprintf("Signers (%v): %v", len(r.Signers), names(r.Signers)),
+		fmt.Sprintf("Missing (%v): %v", len(r.Missing), names(r.Missing)),
+	)
+	if fromNode {
+		lines = append(lines, "Retrieved from the node, the missing validators are the ones of the current set")
+	}
+	return lines
+}
+
+func (u *UI) name(addr common.Address) string {
+	if u.operator != nil {
+		return u.operator(addr)
+	}
+	return addr.Hex()
+}
+
+func names(list []redt.Validator) string {
+	names := make([]string, len(list))
+	for i, v := range list {
+		names[i] = v.Name()
+	}
+	return strings.Join(names, ", ")
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func lastRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		r = r[len(r)-n:]
+	}
+	return string(r)
+}
+
+func lastItems(items []string, n int) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	if len(items) > n {
+		items = items[len(items)-n:]
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,214 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hesusruiz/signers/redt"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	valA = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	valB = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	valC = common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	alpha   = redt.Validator{Address: valA, Operator: "Alpha"}
+	bravo   = redt.Validator{Address: valB, Operator: "Bravo"}
+	charlie = redt.Validator{Address: valC, Operator: "Charlie"}
+)
+
+func testUI() *UI {
+	u := New()
+	u.stats = func() []redt.ValidatorStats {
+		return []redt.ValidatorStats{
+			{Address: valA, Operator: "Alpha", Proposals: 5, Seals: 10, MissedSeals: 0, MissedTurns: 1},
+			{Address: valB, Operator: "Bravo", Proposals: 7, Seals: 8, MissedSeals: 2, MissedTurns: 0},
+			{Address: valC, Operator: "Charlie", Proposals: 0, Seals: 9, MissedSeals: 1, MissedTurns: 3},
+		}
+	}
+	u.operator = func(addr common.Address) string {
+		return map[common.Address]string{valA: "Alpha", valB: "Bravo", valC: "Charlie"}[addr]
+	}
+
+	for n := int64(1); n <= 30; n++ {
+		u.BlockProcessed(&redt.BlockReport{
+			Number:   n,
+			Time:     time.Unix(1000+3*n, 0),
+			Interval: 3,
+			Proposer: alpha,
+			Signers:  []redt.Validator{alpha, bravo},
+			Missing:  []redt.Validator{charlie},
+		})
+	}
+	return u
+}
+
+func operators(rows []redt.ValidatorStats) []string {
+	var names []string
+	for _, r := range rows {
+		names = append(names, r.Operator)
+	}
+	return names
+}
+
+func TestSort(t *testing.T) {
+	u := testUI()
+
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, operators(u.rows()))
+
+	// The counters are sorted from the highest, and the same key reverses the order
+	u.handleKey("2")
+	assert.Equal(t, []string{"Bravo", "Alpha", "Charlie"}, operators(u.rows()))
+	u.handleKey("2")
+	assert.Equal(t, []string{"Charlie", "Alpha", "Bravo"}, operators(u.rows()))
+
+	u.handleKey("5")
+	assert.Equal(t, []string{"Charlie", "Alpha", "Bravo"}, operators(u.rows()))
+
+	u.handleKey("1")
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, operators(u.rows()))
+}
+
+func TestPause(t *testing.T) {
+	u := testUI()
+
+	u.handleKey("p")
+	u.BlockProcessed(&redt.BlockReport{Number: 31, Proposer: bravo})
+	assert.Len(t, u.visibleBlocks(), 30)
+
+	u.handleKey("p")
+	assert.Len(t, u.visibleBlocks(), 31)
+}
+
+func TestNavigation(t *testing.T) {
+	u := testUI()
+
+	// The table has the focus, and enter opens the selected validator
+	u.handleKey("down")
+	u.handleKey("enter")
+	assert.Contains(t, u.detail[0], "Validator Bravo")
+
+	u.handleKey("esc")
+	assert.Nil(t, u.detail)
+
+	// In the log, the selection stops following the new blocks
+	u.handleKey("tab")
+	u.handleKey("up")
+	assert.Equal(t, int64(29), u.selectedBlock().Number)
+	u.BlockProcessed(&redt.BlockReport{Number: 31, Proposer: bravo})
+	assert.Equal(t, int64(29), u.selectedBlock().Number)
+	u.handleKey("f")
+	assert.Equal(t, int64(31), u.selectedBlock().Number)
+
+	u.handleKey("enter")
+	assert.Contains(t, u.detail[0], "Block 31")
+}
+
+func TestJump(t *testing.T) {
+	u := testUI()
+
+	for _, key := range []string{"g", "1", "2", "x", "3", "backspace", "enter"} {
+		u.handleKey(key)
+	}
+	assert.Nil(t, u.prompt)
+	assert.Equal(t, focusLog, u.focus)
+	assert.Equal(t, int64(12), u.selectedBlock().Number)
+	assert.Contains(t, u.detail[0], "Block 12")
+	assert.Equal(t, "Missing (1): Charlie", u.detail[5])
+
+	// Without access to the node, only the blocks in the log
+	u.handleKey("g")
+	u.handleKey("9")
+	u.handleKey("9")
+	u.handleKey("enter")
+	assert.Equal(t, "block 99 is not in the log", u.message)
+
+	// With access to the node, the report of the block is retrieved
+	u.report = func(number int64) (*redt.BlockReport, error) {
+		return &redt.BlockReport{Number: number, Proposer: bravo, Signers: []redt.Validator{bravo}, Missing: []redt.Validator{alpha, charlie}}, nil
+	}
+	u.fetchBlock(99)
+	assert.Contains(t, u.detail[0], "Block 99")
+	assert.Equal(t, "Missing (2): Alpha, Charlie", u.detail[5])
+}
+
+func TestValidatorDetail(t *testing.T) {
+	u := testUI()
+	u.BlockProcessed(&redt.BlockReport{Number: 31, Proposer: bravo, ExpectedProposer: &charlie, MissedTurn: true, Signers: []redt.Validator{bravo}})
+
+	u.showValidator(valC)
+	assert.Equal(t, strings.Repeat(".", 30)+"!", u.detail[3])
+	assert.Contains(t, u.detail[5], "Missed turns in blocks: 31")
+}
+
+func TestRender(t *testing.T) {
+	u := testUI()
+
+	screen := u.render(100, 30)
+	assert.Len(t, screen, 30)
+	for _, l := range screen {
+		assert.LessOrEqual(t, len([]rune(l.text)), 100)
+	}
+
+	assert.Contains(t, screen[0].text, "block 30")
+	assert.Contains(t, screen[1].text, "avg 3.0s  max 3s")
+	assert.Contains(t, screen[4].text, "Alpha")
+	assert.Equal(t, styleReverse, screen[4].style)
+
+	// Charlie did not propose any block
+	assert.Equal(t, styleRed, screen[6].style)
+
+	// The log follows the last block, just above the status line
+	assert.Contains(t, screen[28].text, "       30")
+	assert.Contains(t, screen[28].text, "missing: Charlie")
+
+	// A small screen keeps the top
+	assert.Len(t, u.render(40, 5), 5)
+}
+
+func TestRenderWindows(t *testing.T) {
+	u := testUI()
+	stats := u.stats()
+	for i := range stats {
+		stats[i].Windows = []redt.WindowStats{{Window: "100 blocks", Blocks: 100, Proposals: 33, Seals: 100}}
+	}
+	// Bravo is fine since the start, but did not sign recently
+	stats[1].Windows[0].Seals = 0
+	stats[1].Windows[0].MissedSeals = 100
+	u.stats = func() []redt.ValidatorStats { return stats }
+
+	screen := u.render(140, 30)
+	assert.Contains(t, screen[3].text, "P/S/M 100 block")
+	assert.Contains(t, screen[5].text, "33/0/100")
+	assert.Equal(t, styleRed, screen[5].style)
+
+	u.showValidator(valB)
+	assert.Contains(t, u.detail[1], "100 blocks: 33/0/100/0")
+}
+
+func TestRenderStall(t *testing.T) {
+	u := testUI()
+	u.StallChanged(&redt.StallReport{Stalled: true, LastBlock: 30, LastBlockTime: time.Now().Add(-time.Minute)})
+
+	screen := u.render(120, 30)
+	assert.Contains(t, screen[0].text, "STALLED")
+	assert.Contains(t, screen[2].text, "Chain stalled for 1m0s after block 30")
+	assert.Equal(t, styleRed, screen[2].style)
+
+	u.StallChanged(&redt.StallReport{LastBlock: 31})
+	screen = u.render(120, 30)
+	assert.NotContains(t, screen[0].text, "STALLED")
+	assert.Empty(t, screen[2].text)
+}
+
+func TestSparkline(t *testing.T) {
+	blocks := []*redt.BlockReport{{Interval: 0}, {Interval: 2}, {Interval: 4}, {Interval: 8}}
+	assert.Equal(t, "▁▂▄█", sparkline(blocks))
+}
+
+func TestParseKeys(t *testing.T) {
+	assert.Equal(t, []string{"up", "q", "esc", "pgdn", "enter"}, parseKeys([]byte("\x1b[Aq\x1b\x1b[6~\r")))
+}